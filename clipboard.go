@@ -0,0 +1,133 @@
+package vermui
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/verdverm/tview"
+
+	"github.com/verdverm/vermui/events"
+)
+
+// Copier is implemented by widgets which have text to copy,
+// such as a selected row or the contents of a console.
+type Copier interface {
+	CopyText() string
+}
+
+// Clipboard key bindings, set them before Init.
+// An empty string disables the binding. The keys
+// are not passed on to the focused widget.
+var (
+	CopyKey  = "A-c"
+	PasteKey = "A-v"
+)
+
+// ClipboardOSC52 sends copies to the terminal's clipboard with the
+// OSC 52 escape sequence. Terminals which don't support it ignore it,
+// the internal register is always written.
+var ClipboardOSC52 = os.Getenv("TERM") != "linux" && os.Getenv("TERM") != "dumb"
+
+var clipboard struct {
+	sync.Mutex
+	text string
+}
+
+// Copy puts text on the clipboard and publishes "/clipboard/copied".
+func Copy(text string) error {
+	clipboard.Lock()
+	clipboard.text = text
+	clipboard.Unlock()
+
+	var err error
+	if ClipboardOSC52 {
+		err = writeOSC52(text)
+	}
+
+	go events.SendCustomEvent("/clipboard/copied", text)
+	return err
+}
+
+// ClipboardText returns the contents of the internal register.
+func ClipboardText() string {
+	clipboard.Lock()
+	defer clipboard.Unlock()
+
+	return clipboard.text
+}
+
+// CopyFocused copies the text of the focused widget,
+// if it is a Copier, InputField or TextView.
+func CopyFocused() error {
+	if app == nil {
+		return nil
+	}
+
+	text := ""
+	switch w := GetFocus().(type) {
+	case Copier:
+		text = w.CopyText()
+	case *tview.InputField:
+		text = w.GetText()
+	case *tview.TextView:
+		text = w.GetText(true)
+	default:
+		return nil
+	}
+	if text == "" {
+		return nil
+	}
+	return Copy(text)
+}
+
+// PasteClipboard pastes the internal register into the focused widget.
+func PasteClipboard() {
+	Paste(ClipboardText())
+}
+
+// writeOSC52 asks the terminal to set its clipboard, going
+// around tcell since the screen has no API for it.
+func writeOSC52(text string) error {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+
+	// tmux needs the sequence wrapped in its passthrough
+	if os.Getenv("TMUX") != "" {
+		seq = "\x1bPtmux;" + strings.Replace(seq, "\x1b", "\x1b\x1b", -1) + "\x1b\\"
+	}
+
+	return writeTerminal(seq)
+}
+
+func addClipboardHandlers() {
+	if CopyKey != "" {
+		events.ConsumeKey(CopyKey)
+		events.AddGlobalHandler("/sys/key/"+CopyKey, func(e events.Event) {
+			if err := CopyFocused(); err != nil {
+				go events.SendCustomEvent("/console/warn", fmt.Sprintf("clipboard: %v", err))
+			}
+		})
+	}
+	if PasteKey != "" {
+		events.ConsumeKey(PasteKey)
+		events.AddGlobalHandler("/sys/key/"+PasteKey, func(e events.Event) {
+			PasteClipboard()
+		})
+	}
+
+	// commands for other components and the command box
+	events.AddGlobalHandler("/clipboard/copy", func(e events.Event) {
+		text, ok := e.Data.(*events.EventCustom).Data().(string)
+		if !ok {
+			return
+		}
+		if err := Copy(text); err != nil {
+			go events.SendCustomEvent("/console/warn", fmt.Sprintf("clipboard: %v", err))
+		}
+	})
+	events.AddGlobalHandler("/clipboard/paste", func(e events.Event) {
+		PasteClipboard()
+	})
+}
@@ -0,0 +1,181 @@
+package vermui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/maruel/panicparse/stack"
+	"github.com/pkg/errors"
+
+	"github.com/verdverm/vermui/events"
+)
+
+// CrashConfig controls what happens when vermui catches a panic.
+type CrashConfig struct {
+	// Directory the report is written to, defaults to os.TempDir().
+	Dir string
+
+	// Number of recent events and console lines in the report,
+	// zero means the default.
+	Events       int
+	ConsoleLines int
+
+	// Recover panics inside event handlers, write a report
+	// and keep running instead of crashing.
+	RecoverHandlers bool
+
+	// Re-panic after the report is written, otherwise exit(2).
+	Repanic bool
+
+	// Where the report path is printed, defaults to os.Stderr.
+	Out io.Writer
+
+	// Optional, called after the report is written.
+	Handler func(report string, reason interface{})
+}
+
+var crashConfig = CrashConfig{
+	Events:       64,
+	ConsoleLines: 128,
+}
+
+// SetCrashConfig configures crash handling, call it after Init.
+func SetCrashConfig(cfg CrashConfig) {
+	if cfg.Events == 0 {
+		cfg.Events = 64
+	}
+	if cfg.ConsoleLines == 0 {
+		cfg.ConsoleLines = 128
+	}
+	crashConfig = cfg
+	events.SetRecentSize(cfg.Events, cfg.ConsoleLines)
+
+	if cfg.RecoverHandlers {
+		events.RecoverHandlers(func(e events.Event, r interface{}, st []byte) {
+			report, err := WriteCrashReport(r, st)
+			if err != nil {
+				go events.SendCustomEvent("/console/crit", errors.Wrap(err, "writing crash report"))
+			}
+			go events.SendCustomEvent("/console/crit", fmt.Sprintf("handler for %q panicked: %v (report: %s)", e.Path, r, report))
+			if crashConfig.Handler != nil {
+				crashConfig.Handler(report, r)
+			}
+		})
+	} else {
+		events.RecoverHandlers(nil)
+	}
+}
+
+// crash is deferred at the top of goroutines vermui starts. The terminal
+// is restored, a report is written and its path printed.
+func crash() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	Stop()
+
+	out := crashConfig.Out
+	if out == nil {
+		out = os.Stderr
+	}
+
+	fmt.Fprintf(out, "Captured a panic(value=%v) in vermui, exited and cleaned the terminal.\n", r)
+	report, err := WriteCrashReport(r, debug.Stack())
+	if err != nil {
+		fmt.Fprintf(out, "Unable to write crash report: %v\n", err)
+	} else {
+		fmt.Fprintf(out, "Crash report: %s\n", report)
+	}
+
+	if crashConfig.Handler != nil {
+		crashConfig.Handler(report, r)
+	}
+
+	if crashConfig.Repanic {
+		panic(r)
+	}
+	os.Exit(2)
+}
+
+// WriteCrashReport writes a report with the reason, the stack of the
+// panicking goroutine (if known), all goroutines bucketed by panicparse,
+// and the recent events and console lines. It returns the file's path.
+func WriteCrashReport(reason interface{}, panicStack []byte) (string, error) {
+	dir := crashConfig.Dir
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	f, err := ioutil.TempFile(dir, "vermui-crash-"+time.Now().Format("20060102-150405")+"-")
+	if err != nil {
+		return "", errors.Wrap(err, "in WriteCrashReport")
+	}
+	defer f.Close()
+
+	fmt.Fprintf(f, "vermui crash report\n\ntime:   %s\ngo:     %s\nreason: %v\n",
+		time.Now().Format(time.RFC3339), runtime.Version(), reason)
+
+	if len(panicStack) > 0 {
+		fmt.Fprintf(f, "\n== panic stack ==\n\n%s", panicStack)
+	}
+
+	fmt.Fprintf(f, "\n== goroutines ==\n\n")
+	writeGoroutines(f)
+
+	fmt.Fprintf(f, "\n== recent events ==\n\n")
+	for _, e := range events.Recent() {
+		fmt.Fprintln(f, formatEvent(e))
+	}
+
+	fmt.Fprintf(f, "\n== recent console ==\n\n")
+	for _, e := range events.RecentConsole() {
+		fmt.Fprintln(f, formatEvent(e))
+	}
+
+	return f.Name(), nil
+}
+
+// writeGoroutines dumps and buckets the stacks of all goroutines.
+func writeGoroutines(w io.Writer) {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	gs, err := stack.ParseDump(bytes.NewReader(buf), ioutil.Discard)
+	if err != nil {
+		w.Write(buf)
+		return
+	}
+	p := &stack.Palette{}
+	buckets := stack.SortBuckets(stack.Bucketize(gs, stack.AnyValue))
+	srcLen, pkgLen := stack.CalcLengths(buckets, false)
+	for _, bucket := range buckets {
+		io.WriteString(w, p.BucketHeader(&bucket, false, len(buckets) > 1))
+		io.WriteString(w, p.StackLines(&bucket.Signature, srcLen, pkgLen, false))
+	}
+}
+
+func formatEvent(e events.Event) string {
+	d := e.Data
+	switch t := e.Data.(type) {
+	case *events.EventCustom:
+		d = t.Data()
+	case events.EventKey:
+		d = t.KeyStr
+	case events.EventMouse:
+		d = fmt.Sprintf("%s %s (%d,%d) -> %q", t.Action, t.Press, t.X, t.Y, t.Target)
+	}
+	return fmt.Sprintf("[%s] %-8s %-24s %v", e.When().Format("15:04:05.000"), e.From, e.Path, d)
+}
@@ -53,6 +53,16 @@ type EventKey struct {
 type EventMouse struct {
 	*tcell.EventMouse
 	Press string
+
+	X, Y int
+
+	// Action is set on derived events: down, up, click, double-click,
+	// enter, leave, drag-start, drag-move or drag-end.
+	Action string
+
+	// Target is the id of the widget under the pointer
+	// (or the widget a drag started on).
+	Target string
 }
 
 type EventInterrupt struct {
@@ -85,18 +95,100 @@ func SendCustomEvent(path string, data interface{}) {
 	customEventCh <- Event(e)
 }
 
+// consumedKeys are the keys which only go to the event handlers,
+// see ConsumeKey.
+var consumedKeys = struct {
+	sync.Mutex
+	keys map[string]bool
+}{keys: make(map[string]bool)}
+
+// ConsumeKey keeps a key, "A-c" say, from the focused widget, so
+// a binding of it does not also type it. The key still goes to
+// the event handlers.
+func ConsumeKey(key string) {
+	consumedKeys.Lock()
+	defer consumedKeys.Unlock()
+
+	consumedKeys.keys[key] = true
+}
+
+func keyConsumed(e Event) bool {
+	k, ok := e.Data.(EventKey)
+	if !ok {
+		return false
+	}
+	consumedKeys.Lock()
+	defer consumedKeys.Unlock()
+
+	return consumedKeys.keys[k.KeyStr]
+}
+
 func hookEventsFromApp(app *tview.Application) {
-	hook := func(e tcell.Event) tcell.Event {
+	defaultPaste.post = func(e tcell.Event) {
+		if s := app.Screen(); s != nil {
+			s.PostEvent(e)
+		}
+	}
+
+	// send puts an event on the event streams, it returns
+	// false for keys which don't go on to the focused widget
+	send := func(e tcell.Event) bool {
+		evts := []Event{handleEvents(e)}
+		if _, ok := e.(*tcell.EventMouse); ok {
+			evts = defaultMouse.track(evts[0])
+		}
 		for _, c := range sysEvtChs {
 			func(ch chan Event) {
-				ch <- handleEvents(e)
+				for _, ne := range evts {
+					ch <- ne
+				}
 			}(c)
 		}
+		return !keyConsumed(evts[0])
+	}
+
+	hook := func(e tcell.Event) tcell.Event {
+		// swallow the keys of a paste, sending one event at the end
+		if pe, replay, swallow := defaultPaste.capture(e); swallow {
+			if pe != nil {
+				for _, c := range sysEvtChs {
+					c <- *pe
+				}
+			}
+			// keys held for a marker go to the focused widget from
+			// here, posting them again would put them behind the
+			// keys typed after them
+			for _, r := range replay {
+				if send(r) {
+					deliverKey(app, r)
+				}
+			}
+			return nil
+		}
+
+		if !send(e) {
+			return nil
+		}
 		return e
 	}
 	app.SetInputCapture(hook)
 }
 
+// deliverKey hands a key to the focused widget, as tview
+// does with the keys the input capture lets through.
+func deliverKey(app *tview.Application, e tcell.Event) {
+	p := app.GetFocus()
+	if p == nil {
+		return
+	}
+	if handler := p.InputHandler(); handler != nil {
+		handler(e, func(p tview.Primitive) {
+			app.SetFocus(p)
+		})
+		app.Draw()
+	}
+}
+
 func handleEvents(e tcell.Event) Event {
 	ne := Event{Event: e, From: "/sys"}
 
@@ -166,35 +258,8 @@ func eventMouse(e *tcell.EventMouse) (te EventMouse) {
 	te.EventMouse = e
 
 	mods := eventMods(e.Modifiers())
-
-	btn := ""
-	switch B := e.Buttons(); B {
-	case B & tcell.Button1:
-		btn = "<left>"
-	case B & tcell.Button2:
-		btn = "<middle>"
-	case B & tcell.Button3:
-		btn = "<right>"
-
-	case B & tcell.Button4:
-		btn = "<button-4>"
-	case B & tcell.Button5:
-		btn = "<button-5>"
-
-	case B & tcell.WheelUp:
-		btn = "<wheel-up>"
-	case B & tcell.WheelDown:
-		btn = "<wheel-down>"
-	case B & tcell.WheelLeft:
-		btn = "<wheel-left>"
-	case B & tcell.WheelRight:
-		btn = "<wheel-right>"
-
-	default:
-		btn = "<unknown>"
-	}
-
-	te.Press = mods + btn
+	te.Press = mods + buttonNames(e.Buttons())
+	te.X, te.Y = e.Position()
 
 	return te
 }
@@ -224,6 +289,11 @@ type EventStream struct {
 	sigStopLoop chan Event
 	Handlers    map[string]func(Event)
 	hook        func(Event)
+
+	// not under the RWMutex, handlers run holding it
+	recentMu sync.RWMutex
+	recent   *eventRing
+	console  *eventRing
 }
 
 func NewEventStream() *EventStream {
@@ -232,6 +302,8 @@ func NewEventStream() *EventStream {
 		stream:      make(chan Event, 256),
 		Handlers:    make(map[string]func(Event)),
 		sigStopLoop: make(chan Event),
+		recent:      newEventRing(64),
+		console:     newEventRing(128),
 	}
 }
 
@@ -289,11 +361,12 @@ func (es *EventStream) Loop() {
 		case "/sig/stoploop":
 			return
 		}
+		es.record(e)
 		func(a Event) {
 			es.RLock()
 			defer es.RUnlock()
 			if pattern := es.match(a.Path); pattern != "" {
-				es.Handlers[pattern](a)
+				callHandler(es.Handlers[pattern], a)
 			}
 		}(e)
 
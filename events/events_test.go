@@ -0,0 +1,28 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell"
+)
+
+func TestConsumeKey(t *testing.T) {
+	ConsumeKey("A-c")
+	defer delete(consumedKeys.keys, "A-c")
+
+	tests := []struct {
+		event tcell.Event
+		want  bool
+	}{
+		{tcell.NewEventKey(tcell.KeyRune, 'c', tcell.ModAlt), true},
+		{tcell.NewEventKey(tcell.KeyRune, 'c', tcell.ModNone), false},
+		{tcell.NewEventKey(tcell.KeyRune, 'v', tcell.ModAlt), false},
+		{tcell.NewEventMouse(1, 1, tcell.Button1, tcell.ModAlt), false},
+	}
+	for _, test := range tests {
+		e := handleEvents(test.event)
+		if got := keyConsumed(e); got != test.want {
+			t.Errorf("%s: consumed %v, want %v", e.Path, got, test.want)
+		}
+	}
+}
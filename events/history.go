@@ -0,0 +1,107 @@
+package events
+
+import (
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// eventRing keeps the last N events which went through a stream.
+type eventRing struct {
+	sync.Mutex
+	evts []Event
+	next int
+	full bool
+}
+
+func newEventRing(size int) *eventRing {
+	return &eventRing{
+		evts: make([]Event, size),
+	}
+}
+
+func (R *eventRing) add(e Event) {
+	R.Lock()
+	defer R.Unlock()
+
+	if len(R.evts) == 0 {
+		return
+	}
+	R.evts[R.next] = e
+	R.next = (R.next + 1) % len(R.evts)
+	if R.next == 0 {
+		R.full = true
+	}
+}
+
+// list returns the events, oldest first.
+func (R *eventRing) list() []Event {
+	R.Lock()
+	defer R.Unlock()
+
+	if !R.full {
+		return append([]Event{}, R.evts[:R.next]...)
+	}
+	ret := append([]Event{}, R.evts[R.next:]...)
+	return append(ret, R.evts[:R.next]...)
+}
+
+func (es *EventStream) record(e Event) {
+	recent, console := es.rings()
+	recent.add(e)
+	if strings.HasPrefix(e.Path, "/console") {
+		console.add(e)
+	}
+}
+
+func (es *EventStream) rings() (recent, console *eventRing) {
+	es.recentMu.RLock()
+	defer es.recentMu.RUnlock()
+	return es.recent, es.console
+}
+
+// Recent returns the last events handled by the default stream, oldest first.
+func Recent() []Event {
+	recent, _ := defaultEventStream.rings()
+	return recent.list()
+}
+
+// RecentConsole returns the last "/console" events, oldest first.
+func RecentConsole() []Event {
+	_, console := defaultEventStream.rings()
+	return console.list()
+}
+
+// SetRecentSize sets how many events and console events are kept,
+// any already recorded are dropped. It is safe while the loop runs.
+func SetRecentSize(evts, console int) {
+	es := defaultEventStream
+	es.recentMu.Lock()
+	defer es.recentMu.Unlock()
+	es.recent = newEventRing(evts)
+	es.console = newEventRing(console)
+}
+
+// HandlerPanicFunc is called when a handler panics,
+// with the event being handled and the recovered value.
+type HandlerPanicFunc func(e Event, r interface{}, stack []byte)
+
+var handlerPanic HandlerPanicFunc
+
+// RecoverHandlers makes panics inside handlers recoverable,
+// f is called and event processing continues.
+// Passing nil lets panics crash the event loop again.
+func RecoverHandlers(f HandlerPanicFunc) {
+	handlerPanic = f
+}
+
+func callHandler(h func(Event), e Event) {
+	if f := handlerPanic; f != nil {
+		defer func() {
+			if r := recover(); r != nil {
+				f(e, r, debug.Stack())
+			}
+		}()
+	}
+	h(e)
+}
@@ -0,0 +1,38 @@
+package events
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSetRecentSize(t *testing.T) {
+	old := defaultEventStream
+	defaultEventStream = NewEventStream()
+	defer func() { defaultEventStream = old }()
+
+	// resizing while the loop records, run with -race
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			defaultEventStream.record(Event{Path: "/console/log"})
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		SetRecentSize(4, 2)
+	}
+	wg.Wait()
+
+	SetRecentSize(4, 2)
+	for i := 0; i < 6; i++ {
+		defaultEventStream.record(Event{Path: "/console/log"})
+		defaultEventStream.record(Event{Path: "/sys/key"})
+	}
+	if n := len(Recent()); n != 4 {
+		t.Errorf("expected 4 recent events, got %d", n)
+	}
+	if n := len(RecentConsole()); n != 2 {
+		t.Errorf("expected 2 console events, got %d", n)
+	}
+}
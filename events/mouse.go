@@ -0,0 +1,255 @@
+package events
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell"
+	"github.com/verdverm/tview"
+)
+
+// DoubleClickInterval is the maximum time between two clicks
+// on the same cell for them to count as a double-click.
+var DoubleClickInterval = 400 * time.Millisecond
+
+const mouseButtons = tcell.Button1 | tcell.Button2 | tcell.Button3 | tcell.Button4 | tcell.Button5
+
+var mouseButtonNames = []struct {
+	mask tcell.ButtonMask
+	name string
+}{
+	{tcell.Button1, "<left>"},
+	{tcell.Button2, "<middle>"},
+	{tcell.Button3, "<right>"},
+	{tcell.Button4, "<button-4>"},
+	{tcell.Button5, "<button-5>"},
+	{tcell.WheelUp, "<wheel-up>"},
+	{tcell.WheelDown, "<wheel-down>"},
+	{tcell.WheelLeft, "<wheel-left>"},
+	{tcell.WheelRight, "<wheel-right>"},
+}
+
+// buttonNames returns the key-style names of all buttons set in the mask.
+func buttonNames(B tcell.ButtonMask) string {
+	names := []string{}
+	for _, b := range mouseButtonNames {
+		if B&b.mask != 0 {
+			names = append(names, b.name)
+		}
+	}
+	if len(names) == 0 {
+		return "<none>"
+	}
+	return strings.Join(names, "")
+}
+
+// mouseTracker turns the raw tcell mouse stream into
+// targeted press, click, hover and drag events.
+// It is only used from the tview event goroutine.
+type mouseTracker struct {
+	buttons    tcell.ButtonMask
+	mods       string
+	downX      int
+	downY      int
+	downTarget string
+	dragging   bool
+
+	lastClick    time.Time
+	lastClickX   int
+	lastClickY   int
+	lastClickBtn tcell.ButtonMask
+
+	hover string
+}
+
+var defaultMouse = &mouseTracker{}
+
+// track fills in the target of a raw mouse event and
+// returns it along with any events derived from it.
+func (mt *mouseTracker) track(raw Event) []Event {
+	m := raw.Data.(EventMouse)
+	x, y := m.X, m.Y
+	target := hitTest(x, y)
+
+	m.Target = target
+	raw.To = target
+	raw.Data = m
+
+	evts := []Event{raw}
+	derive := func(action, path, to string) {
+		d := m
+		d.Action = action
+		d.Target = to
+		evts = append(evts, Event{
+			Event: raw.Event,
+			Type:  "mouse",
+			From:  raw.From,
+			To:    to,
+			Path:  "/sys/mouse/" + path,
+			Data:  d,
+		})
+	}
+
+	// hover enter / leave
+	if target != mt.hover {
+		if mt.hover != "" {
+			derive("leave", "leave", mt.hover)
+		}
+		if target != "" {
+			derive("enter", "enter", target)
+		}
+		mt.hover = target
+	}
+
+	prev := mt.buttons
+	btns := m.Buttons() & mouseButtons
+	now := raw.When()
+
+	switch {
+	// press
+	case prev == 0 && btns != 0:
+		mt.buttons = btns
+		mt.mods = eventMods(m.Modifiers())
+		mt.downX, mt.downY = x, y
+		mt.downTarget = target
+		mt.dragging = false
+		derive("down", "down/"+mt.mods+buttonNames(btns), target)
+
+	// movement with a button held
+	case prev != 0 && btns != 0:
+		if !mt.dragging && (x != mt.downX || y != mt.downY) {
+			mt.dragging = true
+			derive("drag-start", "drag/start/"+mt.mods+buttonNames(prev), mt.downTarget)
+		}
+		if mt.dragging {
+			derive("drag-move", "drag/move/"+mt.mods+buttonNames(prev), mt.downTarget)
+		}
+
+	// release
+	case prev != 0 && btns == 0:
+		name := mt.mods + buttonNames(prev)
+		derive("up", "up/"+name, target)
+		if mt.dragging {
+			derive("drag-end", "drag/end/"+name, mt.downTarget)
+		} else if target == mt.downTarget {
+			derive("click", "click/"+name, target)
+			if prev == mt.lastClickBtn && x == mt.lastClickX && y == mt.lastClickY &&
+				now.Sub(mt.lastClick) <= DoubleClickInterval {
+				derive("double-click", "double-click/"+name, target)
+				// a third click starts over
+				mt.lastClick = time.Time{}
+			} else {
+				mt.lastClick = now
+				mt.lastClickX, mt.lastClickY = x, y
+				mt.lastClickBtn = prev
+			}
+		}
+		mt.buttons = 0
+		mt.dragging = false
+	}
+
+	return evts
+}
+
+// frame tracks which widgets are drawn, so hidden and
+// undrawn widgets with an old rectangle are not hit.
+// It is guarded by wgtMgrMuxtx.
+var frame struct {
+	saved map[string]rect // rects from before the frame
+	drawn map[string]rect // laid out in the last frame
+}
+
+type rect struct{ x, y, w, h int }
+
+// BeginFrame is called before drawing a frame. It empties the
+// rectangles of the registered widgets, containers lay out the
+// widgets they draw again, see EndFrame.
+func BeginFrame() {
+	wgtMgrMuxtx.Lock()
+	defer wgtMgrMuxtx.Unlock()
+
+	frame.saved = make(map[string]rect)
+	for _, w := range defaultWgtMgr {
+		if w.WgtRef == nil {
+			continue
+		}
+		x, y, wd, ht := w.WgtRef.GetRect()
+		frame.saved[w.Id] = rect{x, y, wd, ht}
+		w.WgtRef.SetRect(0, 0, 0, 0)
+	}
+}
+
+// EndFrame is called after drawing a frame. Widgets which were laid
+// out are hit targets with their rectangle until the next frame, the
+// others get their rectangle back.
+func EndFrame() {
+	wgtMgrMuxtx.Lock()
+	defer wgtMgrMuxtx.Unlock()
+
+	frame.drawn = make(map[string]rect)
+	for _, w := range defaultWgtMgr {
+		r, ok := frame.saved[w.Id]
+		if w.WgtRef == nil || !ok {
+			continue
+		}
+		x, y, wd, ht := w.WgtRef.GetRect()
+		if wd > 0 && ht > 0 {
+			frame.drawn[w.Id] = rect{x, y, wd, ht}
+		} else if x == 0 && y == 0 && wd == 0 && ht == 0 {
+			w.WgtRef.SetRect(r.x, r.y, r.w, r.h)
+		}
+	}
+	frame.saved = nil
+}
+
+// hitTest returns the id of the smallest registered widget
+// drawn in the last frame whose rectangle in that frame
+// contains the point, or "" if there is none.
+//
+// Only widgets known to the widget manager are considered,
+// see AddWidget. Those have to be laid out by their container
+// every frame, like the items of a Flex or a Grid are.
+func hitTest(x, y int) string {
+	wgtMgrMuxtx.Lock()
+	defer wgtMgrMuxtx.Unlock()
+
+	id := ""
+	area := -1
+	for wid, r := range frame.drawn {
+		if _, ok := defaultWgtMgr[wid]; !ok {
+			continue
+		}
+		if x < r.x || y < r.y || x >= r.x+r.w || y >= r.y+r.h {
+			continue
+		}
+		a := r.w * r.h
+		if area < 0 || a < area || (a == area && wid > id) {
+			id = wid
+			area = a
+		}
+	}
+	return id
+}
+
+// AddWidget registers a widget with the widget manager without
+// any handlers, which makes it a target for mouse events once
+// it is drawn, see BeginFrame.
+func AddWidget(wgt tview.Primitive) {
+	wgtMgrMuxtx.Lock()
+	defer wgtMgrMuxtx.Unlock()
+
+	if _, ok := defaultWgtMgr[wgt.Id()]; !ok {
+		defaultWgtMgr.AddWgt(wgt)
+	}
+}
+
+// GetWidget returns the registered widget with the given id, or nil.
+func GetWidget(id string) tview.Primitive {
+	wgtMgrMuxtx.Lock()
+	defer wgtMgrMuxtx.Unlock()
+
+	if w, ok := defaultWgtMgr[id]; ok {
+		return w.WgtRef
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package events
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gdamore/tcell"
+	"github.com/verdverm/tview"
+)
+
+func TestMouseTracker(t *testing.T) {
+	defaultWgtMgr = NewWgtMgr()
+	left := tview.NewBox()
+	right := tview.NewBox()
+	AddWidget(left)
+	AddWidget(right)
+	BeginFrame()
+	left.SetRect(0, 0, 10, 5)
+	right.SetRect(10, 0, 10, 5)
+	EndFrame()
+	l, r := " "+left.Id(), " "+right.Id()
+
+	const none, btn1 = tcell.ButtonNone, tcell.Button1
+	steps := []struct {
+		name string
+		x, y int
+		btn  tcell.ButtonMask
+		want []string
+	}{
+		{"hover", 1, 1, none, []string{"/sys/mouse/<none>" + l, "/sys/mouse/enter" + l}},
+		{"press", 1, 1, btn1, []string{"/sys/mouse/<left>" + l, "/sys/mouse/down/<left>" + l}},
+		{"release", 1, 1, none, []string{"/sys/mouse/<none>" + l, "/sys/mouse/up/<left>" + l, "/sys/mouse/click/<left>" + l}},
+		{"press again", 1, 1, btn1, []string{"/sys/mouse/<left>" + l, "/sys/mouse/down/<left>" + l}},
+		{"double-click", 1, 1, none, []string{"/sys/mouse/<none>" + l, "/sys/mouse/up/<left>" + l, "/sys/mouse/click/<left>" + l, "/sys/mouse/double-click/<left>" + l}},
+
+		{"drag press", 2, 2, btn1, []string{"/sys/mouse/<left>" + l, "/sys/mouse/down/<left>" + l}},
+		{"drag onto right", 12, 2, btn1, []string{
+			"/sys/mouse/<left>" + r,
+			"/sys/mouse/leave" + l,
+			"/sys/mouse/enter" + r,
+			"/sys/mouse/drag/start/<left>" + l,
+			"/sys/mouse/drag/move/<left>" + l,
+		}},
+		{"drag move", 13, 2, btn1, []string{"/sys/mouse/<left>" + r, "/sys/mouse/drag/move/<left>" + l}},
+		{"drop", 13, 2, none, []string{"/sys/mouse/<none>" + r, "/sys/mouse/up/<left>" + r, "/sys/mouse/drag/end/<left>" + l}},
+
+		{"press right", 13, 2, btn1, []string{"/sys/mouse/<left>" + r, "/sys/mouse/down/<left>" + r}},
+		{"release left, no click", 3, 3, none, []string{
+			"/sys/mouse/<none>" + l,
+			"/sys/mouse/leave" + r,
+			"/sys/mouse/enter" + l,
+			"/sys/mouse/up/<left>" + l,
+		}},
+
+		{"scroll", 3, 3, tcell.WheelUp, []string{"/sys/mouse/<wheel-up>" + l}},
+		{"leave all", 30, 3, none, []string{"/sys/mouse/<none> ", "/sys/mouse/leave" + l}},
+	}
+
+	mt := &mouseTracker{}
+	for _, s := range steps {
+		evts := mt.track(handleEvents(tcell.NewEventMouse(s.x, s.y, s.btn, tcell.ModNone)))
+		got := []string{}
+		for _, e := range evts {
+			got = append(got, e.Path+" "+e.To)
+		}
+		if !reflect.DeepEqual(got, s.want) {
+			t.Errorf("%s:\n got  %q\n want %q", s.name, got, s.want)
+		}
+	}
+}
+
+func TestHitTestDrawn(t *testing.T) {
+	defaultWgtMgr = NewWgtMgr()
+	root := tview.NewBox()
+	popup := tview.NewBox()
+	undrawn := tview.NewBox()
+	undrawn.SetRect(0, 0, 15, 10)
+	AddWidget(root)
+	AddWidget(popup)
+	AddWidget(undrawn)
+
+	// the popup is shown, the other box never drawn
+	BeginFrame()
+	root.SetRect(0, 0, 80, 24)
+	popup.SetRect(4, 2, 20, 10)
+	EndFrame()
+	if id := hitTest(1, 1); id != root.Id() {
+		t.Errorf("undrawn widget: got %q, want the root %q", id, root.Id())
+	}
+	if id := hitTest(5, 3); id != popup.Id() {
+		t.Errorf("popup: got %q, want %q", id, popup.Id())
+	}
+
+	// the popup is hidden, it keeps its rect but is not hit
+	BeginFrame()
+	root.SetRect(0, 0, 80, 24)
+	EndFrame()
+	if id := hitTest(5, 3); id != root.Id() {
+		t.Errorf("hidden popup: got %q, want the root %q", id, root.Id())
+	}
+	if x, y, w, h := popup.GetRect(); x != 4 || y != 2 || w != 20 || h != 10 {
+		t.Errorf("hidden popup: rect %d,%d %dx%d, want it kept", x, y, w, h)
+	}
+}
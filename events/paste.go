@@ -0,0 +1,186 @@
+package events
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/gdamore/tcell"
+)
+
+// PasteMarkerTimeout is how long an Alt-[ is held to see if it starts
+// a paste marker. Terminals send a marker at once, so when nothing
+// follows in this time the key was typed and is let through.
+var PasteMarkerTimeout = 50 * time.Millisecond
+
+// EventPaste is the data of a "/sys/paste" event, the
+// tcell event of the Event is the last key of the paste.
+type EventPaste struct {
+	Text string
+}
+
+// The markers a terminal in bracketed paste mode puts around pastes,
+// ESC [ 200 ~ and ESC [ 201 ~. tcell has no sequences for them, so it
+// delivers each as an Alt-[ key followed by the runes of the rest.
+const (
+	pasteStart = "200~"
+	pasteEnd   = "201~"
+)
+
+// pasteBuffer collects the keys between the start and end
+// of a bracketed paste so they can be sent as a single event.
+// It is only used from the tview event goroutine.
+//
+// Runes, Enter, Tab and control keys are kept. Other special keys,
+// which tcell decoded from escape sequences in the pasted text, such
+// as the arrows, are dropped.
+type pasteBuffer struct {
+	active bool
+	text   bytes.Buffer
+
+	// the keys which may be a marker, held until it is known
+	marker []*tcell.EventKey
+	held   int // counts the markers held, for their timeouts
+
+	// post sends an event through tcell, for the timeouts
+	post func(tcell.Event)
+}
+
+var defaultPaste = &pasteBuffer{}
+
+// markerTimeout is the data of the interrupt posted when held keys
+// have waited PasteMarkerTimeout, it is the count of the marker.
+type markerTimeout int
+
+// capture reports whether the tcell event is part of a paste, or may
+// be, and should be swallowed. When the paste ends, the returned event
+// is the "/sys/paste" event with the full text. When held keys turn out
+// not to be a marker, or nothing follows them in time, they are
+// returned to be delivered, in order.
+func (pb *pasteBuffer) capture(e tcell.Event) (paste *Event, replay []tcell.Event, swallow bool) {
+	if i, ok := e.(*tcell.EventInterrupt); ok {
+		t, ok := i.Data().(markerTimeout)
+		if !ok {
+			return nil, nil, false
+		}
+		if int(t) == pb.held && len(pb.marker) > 0 {
+			replay = pb.release()
+		}
+		return nil, replay, true
+	}
+	k, ok := e.(*tcell.EventKey)
+	if !ok {
+		return nil, nil, false
+	}
+
+	if len(pb.marker) > 0 || (k.Key() == tcell.KeyRune && k.Rune() == '[' && k.Modifiers() == tcell.ModAlt) {
+		if len(pb.marker) == 0 {
+			pb.held++
+			if pb.post != nil {
+				t := markerTimeout(pb.held)
+				time.AfterFunc(PasteMarkerTimeout, func() {
+					pb.post(tcell.NewEventInterrupt(t))
+				})
+			}
+		}
+		pb.marker = append(pb.marker, k)
+		switch pb.markerState() {
+		case markerPartial:
+			return nil, nil, true
+
+		case markerStart:
+			pb.marker = nil
+			if !pb.active {
+				pb.active = true
+				pb.text.Reset()
+			}
+			return nil, nil, true
+
+		case markerEnd:
+			pb.marker = nil
+			if !pb.active {
+				return nil, nil, true
+			}
+			pb.active = false
+			paste = &Event{
+				Event: e,
+				From:  "/sys",
+				Type:  "paste",
+				Path:  "/sys/paste",
+				Data:  EventPaste{Text: pb.text.String()},
+			}
+			pb.text.Reset()
+			return paste, nil, true
+		}
+
+		// not a marker after all
+		return nil, pb.release(), true
+	}
+
+	if !pb.active {
+		return nil, nil, false
+	}
+	pb.add(k)
+	return nil, nil, true
+}
+
+type markerState int
+
+const (
+	markerNone markerState = iota
+	markerPartial
+	markerStart
+	markerEnd
+)
+
+// markerState checks the held keys, after the Alt-[, against the markers.
+func (pb *pasteBuffer) markerState() markerState {
+	rest := ""
+	for _, k := range pb.marker[1:] {
+		if k.Key() != tcell.KeyRune || k.Modifiers() != tcell.ModNone {
+			return markerNone
+		}
+		rest += string(k.Rune())
+	}
+	switch {
+	case rest == pasteStart:
+		return markerStart
+	case rest == pasteEnd:
+		return markerEnd
+	case len(rest) < len(pasteStart) && (rest == pasteStart[:len(rest)] || rest == pasteEnd[:len(rest)]):
+		return markerPartial
+	}
+	return markerNone
+}
+
+// release lets go of the held keys, which are part of the text in a
+// paste and returned otherwise.
+func (pb *pasteBuffer) release() (replay []tcell.Event) {
+	held := pb.marker
+	pb.marker = nil
+	for _, h := range held {
+		if pb.active {
+			pb.add(h)
+		} else {
+			replay = append(replay, h)
+		}
+	}
+	return replay
+}
+
+// add writes a pasted key to the text.
+func (pb *pasteBuffer) add(k *tcell.EventKey) {
+	switch key := k.Key(); {
+	case key == tcell.KeyRune:
+		if k.Modifiers()&tcell.ModAlt != 0 {
+			// tcell reads ESC and the next rune as Alt
+			pb.text.WriteByte('\x1b')
+		}
+		pb.text.WriteRune(k.Rune())
+	case key == tcell.KeyEnter, key == tcell.KeyLF:
+		pb.text.WriteByte('\n')
+	case key == tcell.KeyTab:
+		pb.text.WriteByte('\t')
+	case key < ' ' || key == tcell.KeyDEL:
+		pb.text.WriteByte(byte(key))
+	}
+}
@@ -0,0 +1,92 @@
+package events
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell"
+)
+
+func keys(s string) []tcell.Event {
+	evts := []tcell.Event{}
+	esc := false
+	for _, r := range s {
+		switch {
+		case r == '\x1b':
+			esc = true
+			continue
+		case esc:
+			evts = append(evts, tcell.NewEventKey(tcell.KeyRune, r, tcell.ModAlt))
+		case r == '\r':
+			evts = append(evts, tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+		default:
+			evts = append(evts, tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone))
+		}
+		esc = false
+	}
+	return evts
+}
+
+func TestPasteBuffer(t *testing.T) {
+	pb := &pasteBuffer{}
+
+	var paste *Event
+	for _, e := range keys("\x1b[200~ls \x1b[A|\r\x1b[201~") {
+		p, replay, swallow := pb.capture(e)
+		if !swallow || len(replay) > 0 {
+			t.Fatalf("paste key %v: swallow %v, replay %v", e, swallow, replay)
+		}
+		if p != nil {
+			paste = p
+		}
+	}
+	if paste == nil || paste.Path != "/sys/paste" {
+		t.Fatalf("no paste event: %v", paste)
+	}
+	if text := paste.Data.(EventPaste).Text; text != "ls \x1b[A|\n" {
+		t.Errorf("paste text %q", text)
+	}
+
+	// Alt-[ typed by hand is held, then sent again
+	evts := keys("\x1b[2x")
+	for _, e := range evts[:2] {
+		if _, replay, swallow := pb.capture(e); !swallow || replay != nil {
+			t.Fatalf("held key %v: swallow %v, replay %v", e, swallow, replay)
+		}
+	}
+	_, replay, swallow := pb.capture(evts[2])
+	if !swallow || !reflect.DeepEqual(replay, evts) {
+		t.Fatalf("mismatch: swallow %v, replay %v, want the keys in order", swallow, replay)
+	}
+}
+
+func TestPasteMarkerTimeout(t *testing.T) {
+	posted := make(chan tcell.Event, 4)
+	pb := &pasteBuffer{post: func(e tcell.Event) { posted <- e }}
+	defer func(d time.Duration) { PasteMarkerTimeout = d }(PasteMarkerTimeout)
+	PasteMarkerTimeout = time.Millisecond
+
+	// a lone Alt-[ is let through when nothing follows
+	evts := keys("\x1b[")
+	if _, _, swallow := pb.capture(evts[0]); !swallow {
+		t.Fatal("Alt-[ not held")
+	}
+	timeout := <-posted
+	_, replay, swallow := pb.capture(timeout)
+	if !swallow || !reflect.DeepEqual(replay, evts) {
+		t.Fatalf("timeout: swallow %v, replay %v, want the Alt-[", swallow, replay)
+	}
+
+	// the timeout of a marker which was completed does nothing
+	for _, e := range keys("\x1b[200~") {
+		pb.capture(e)
+	}
+	timeout = <-posted
+	if _, replay, swallow := pb.capture(timeout); !swallow || replay != nil {
+		t.Fatalf("late timeout: swallow %v, replay %v", swallow, replay)
+	}
+	if !pb.active {
+		t.Error("paste not started")
+	}
+}
@@ -57,6 +57,9 @@ func ClearGlobalHandlers() {
 }
 
 func AddWidgetHandler(wgt tview.Primitive, path string, handler func(Event)) {
+	wgtMgrMuxtx.Lock()
+	defer wgtMgrMuxtx.Unlock()
+
 	if _, ok := defaultWgtMgr[wgt.Id()]; !ok {
 		defaultWgtMgr.AddWgt(wgt)
 	}
@@ -65,6 +68,9 @@ func AddWidgetHandler(wgt tview.Primitive, path string, handler func(Event)) {
 }
 
 func RemoveWidgetHandler(wgt tview.Primitive, path string) {
+	wgtMgrMuxtx.Lock()
+	defer wgtMgrMuxtx.Unlock()
+
 	_, ok := defaultWgtMgr[wgt.Id()]
 	if !ok {
 		return
@@ -74,6 +80,9 @@ func RemoveWidgetHandler(wgt tview.Primitive, path string) {
 }
 
 func ClearWidgetHandlers(wgt tview.Primitive) {
+	wgtMgrMuxtx.Lock()
+	defer wgtMgrMuxtx.Unlock()
+
 	_, ok := defaultWgtMgr[wgt.Id()]
 	if !ok {
 		return
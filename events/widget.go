@@ -79,12 +79,23 @@ func GenId() string {
 
 func (wm WgtMgr) WgtHandlersHook() func(Event) {
 	return func(e Event) {
+		// find the handlers under the lock, but call them without
+		// it, since handlers add and remove handlers themselves
+		wgtMgrMuxtx.Lock()
+		handlers := []func(Event){}
 		for _, v := range wm {
+			// targeted events (e.g. mouse) only go to their widget
+			if e.To != "" && e.To != v.Id {
+				continue
+			}
 			if k := findMatch(v.Handlers, e.Path); k != "" {
-				// v.WgtRef.Lock()
-				v.Handlers[k](e)
-				// v.WgtRef.Unlock()
+				handlers = append(handlers, v.Handlers[k])
 			}
 		}
+		wgtMgrMuxtx.Unlock()
+
+		for _, h := range handlers {
+			callHandler(h, e)
+		}
 	}
 }
@@ -0,0 +1,451 @@
+package cmdbox
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/verdverm/vermui/events"
+)
+
+// Tokenize splits input into words like a shell would.
+// Words are separated by whitespace, single quotes keep everything
+// literally, double quotes and bare words allow backslash escapes.
+func Tokenize(input string) ([]string, error) {
+	words, _, err := lex(input, false, nil)
+	return words, err
+}
+
+// lex is Tokenize, if ops is true a bare '|' or '>' is a word of its
+// own, marked in isOp. If expand is not nil, it is called with the
+// text of words outside of single quotes, an escaped '$' is passed
+// to it as "$$".
+func lex(input string, ops bool, expand func(string) string) (words []string, isOp []bool, err error) {
+	words = []string{}
+	var word, pending bytes.Buffer
+	inWord := false
+	flush := func() {
+		if pending.Len() > 0 {
+			word.WriteString(expand(pending.String()))
+			pending.Reset()
+		}
+	}
+	// write adds text which is expanded when there is an expander
+	write := func(r rune) {
+		if expand == nil {
+			word.WriteRune(r)
+		} else {
+			pending.WriteRune(r)
+		}
+	}
+	endWord := func() {
+		if inWord {
+			flush()
+			words = append(words, word.String())
+			isOp = append(isOp, false)
+			word.Reset()
+			inWord = false
+		}
+	}
+
+	var quote rune
+	escaped := false
+	for _, r := range input {
+		switch {
+		case escaped:
+			if r == '$' && expand != nil {
+				pending.WriteString("$$")
+			} else {
+				write(r)
+			}
+			escaped = false
+
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+
+		case r == '\\':
+			escaped = true
+			inWord = true
+
+		case quote == '"':
+			if r == '"' {
+				flush()
+				quote = 0
+			} else {
+				write(r)
+			}
+
+		case r == '\'' || r == '"':
+			// a variable name ends at a quote
+			flush()
+			quote = r
+			inWord = true
+
+		case r == ' ' || r == '\t' || r == '\n':
+			endWord()
+
+		case ops && (r == '|' || r == '>'):
+			endWord()
+			words = append(words, string(r))
+			isOp = append(isOp, true)
+
+		default:
+			write(r)
+			inWord = true
+		}
+	}
+
+	if escaped {
+		return nil, nil, errors.New("trailing backslash")
+	}
+	if quote != 0 {
+		return nil, nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	endWord()
+	return words, isOp, nil
+}
+
+// Quote is the reverse of Tokenize for a single word.
+func Quote(word string) string {
+	if word == "" {
+		return "''"
+	}
+	if !strings.ContainsAny(word, " \t\n'\"\\|>#$") {
+		return word
+	}
+	return "'" + strings.Replace(word, "'", `'\''`, -1) + "'"
+}
+
+// Join quotes and joins words so Tokenize gives them back.
+func Join(words []string) string {
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = Quote(w)
+	}
+	return strings.Join(quoted, " ")
+}
+
+type ArgType int
+
+const (
+	StringArg ArgType = iota
+	IntArg
+	FloatArg
+	BoolArg
+	DurationArg
+)
+
+func (T ArgType) String() string {
+	switch T {
+	case IntArg:
+		return "int"
+	case FloatArg:
+		return "float"
+	case BoolArg:
+		return "bool"
+	case DurationArg:
+		return "duration"
+	}
+	return "string"
+}
+
+func (T ArgType) parse(value string) (interface{}, error) {
+	switch T {
+	case IntArg:
+		return strconv.Atoi(value)
+	case FloatArg:
+		return strconv.ParseFloat(value, 64)
+	case BoolArg:
+		return strconv.ParseBool(value)
+	case DurationArg:
+		return time.ParseDuration(value)
+	}
+	return value, nil
+}
+
+// Arg is a positional argument.
+type Arg struct {
+	Name     string
+	Type     ArgType
+	Required bool
+	Default  interface{}
+	Help     string
+}
+
+// Flag is a "--name value" or "-s value" option,
+// bool flags take no value.
+type Flag struct {
+	Name    string
+	Short   string
+	Type    ArgType
+	Default interface{}
+	Help    string
+}
+
+// ArgSpec declares the arguments and flags of a command.
+type ArgSpec struct {
+	Args  []Arg
+	Flags []Flag
+
+	// Name of any trailing arguments, which are left in Args.Rest.
+	// If empty, extra arguments are an error.
+	Rest string
+}
+
+// Args are the parsed and validated arguments of a command.
+type Args struct {
+	Values map[string]interface{}
+	Rest   []string
+}
+
+func (A *Args) Get(name string) interface{} {
+	return A.Values[name]
+}
+
+func (A *Args) String(name string) string {
+	s, _ := A.Values[name].(string)
+	return s
+}
+
+func (A *Args) Int(name string) int {
+	i, _ := A.Values[name].(int)
+	return i
+}
+
+func (A *Args) Float(name string) float64 {
+	f, _ := A.Values[name].(float64)
+	return f
+}
+
+func (A *Args) Bool(name string) bool {
+	b, _ := A.Values[name].(bool)
+	return b
+}
+
+func (A *Args) Duration(name string) time.Duration {
+	d, _ := A.Values[name].(time.Duration)
+	return d
+}
+
+func (S *ArgSpec) flag(name string) *Flag {
+	for i, f := range S.Flags {
+		if f.Name == name || (f.Short != "" && f.Short == name) {
+			return &S.Flags[i]
+		}
+	}
+	return nil
+}
+
+// Parse validates args against the spec, filling in defaults.
+func (S *ArgSpec) Parse(args []string) (*Args, error) {
+	A := &Args{
+		Values: make(map[string]interface{}),
+	}
+	for _, f := range S.Flags {
+		if f.Default != nil {
+			A.Values[f.Name] = f.Default
+		} else if f.Type == BoolArg {
+			A.Values[f.Name] = false
+		}
+	}
+
+	pos := []string{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			pos = append(pos, args[i+1:]...)
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			pos = append(pos, arg)
+			continue
+		}
+		// negative numbers are not flags
+		if _, err := strconv.ParseFloat(arg, 64); err == nil {
+			pos = append(pos, arg)
+			continue
+		}
+
+		name := strings.TrimLeft(arg, "-")
+		value, hasValue := "", false
+		if j := strings.Index(name, "="); j >= 0 {
+			name, value, hasValue = name[:j], name[j+1:], true
+		}
+		f := S.flag(name)
+		if f == nil {
+			return nil, fmt.Errorf("unknown flag %q", arg)
+		}
+		if !hasValue {
+			if f.Type == BoolArg {
+				value = "true"
+			} else if i+1 < len(args) {
+				i++
+				value = args[i]
+			} else {
+				return nil, fmt.Errorf("flag %q needs a %s value", arg, f.Type)
+			}
+		}
+		v, err := f.Type.parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("flag %q: bad %s %q", arg, f.Type, value)
+		}
+		A.Values[f.Name] = v
+	}
+
+	for i, a := range S.Args {
+		if i >= len(pos) {
+			if a.Required {
+				return nil, fmt.Errorf("missing argument <%s>", a.Name)
+			}
+			if a.Default != nil {
+				A.Values[a.Name] = a.Default
+			}
+			continue
+		}
+		v, err := a.Type.parse(pos[i])
+		if err != nil {
+			return nil, fmt.Errorf("argument <%s>: bad %s %q", a.Name, a.Type, pos[i])
+		}
+		A.Values[a.Name] = v
+	}
+
+	if len(pos) > len(S.Args) {
+		if S.Rest == "" {
+			return nil, fmt.Errorf("too many arguments, unexpected %q", pos[len(S.Args)])
+		}
+		A.Rest = pos[len(S.Args):]
+	}
+
+	return A, nil
+}
+
+// Usage is the one line usage for the named command.
+func (S *ArgSpec) Usage(name string) string {
+	parts := []string{name}
+	for _, f := range S.Flags {
+		flag := "--" + f.Name
+		if f.Short != "" {
+			flag = "-" + f.Short + "|" + flag
+		}
+		if f.Type != BoolArg {
+			flag += " " + f.Type.String()
+		}
+		parts = append(parts, "["+flag+"]")
+	}
+	for _, a := range S.Args {
+		if a.Required {
+			parts = append(parts, "<"+a.Name+">")
+		} else {
+			parts = append(parts, "["+a.Name+"]")
+		}
+	}
+	if S.Rest != "" {
+		parts = append(parts, "["+S.Rest+"...]")
+	}
+	return strings.Join(parts, " ")
+}
+
+// Help describes every argument and flag, one per line.
+func (S *ArgSpec) Help() string {
+	lines := []string{}
+	for _, a := range S.Args {
+		line := fmt.Sprintf("  %-16s %-8s %s", a.Name, a.Type, a.Help)
+		if a.Default != nil {
+			line += fmt.Sprintf(" (default %v)", a.Default)
+		}
+		lines = append(lines, line)
+	}
+	for _, f := range S.Flags {
+		name := "--" + f.Name
+		if f.Short != "" {
+			name = "-" + f.Short + ", " + name
+		}
+		line := fmt.Sprintf("  %-16s %-8s %s", name, f.Type, f.Help)
+		if f.Default != nil {
+			line += fmt.Sprintf(" (default %v)", f.Default)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ArgsCommand is an optional interface for Commands which declare
+// their arguments. The CmdBoxWidget parses and validates them before
+// the callback runs, the result is in the context under "args".
+type ArgsCommand interface {
+	Command
+	CommandArgs() *ArgSpec
+}
+
+// ParsedArgs returns the Args the CmdBoxWidget put in a callback's context.
+func ParsedArgs(context map[string]interface{}) *Args {
+	if A, ok := context["args"].(*Args); ok {
+		return A
+	}
+	return nil
+}
+
+// SpecCommand is a Command with declared arguments,
+// usage and help are generated from the Spec.
+type SpecCommand struct {
+	Name string
+	Help string
+	Spec *ArgSpec
+
+	Callback func(args *Args, context map[string]interface{})
+
+	// Optional, completes the last of args, see Completer.
+	Complete func(args []string) []string
+}
+
+func (SC *SpecCommand) CommandName() string {
+	return SC.Name
+}
+
+func (SC *SpecCommand) CommandUsage() string {
+	return SC.CommandArgs().Usage(SC.Name)
+}
+
+func (SC *SpecCommand) CommandHelp() string {
+	help := SC.CommandArgs().Help()
+	if help == "" {
+		return SC.Help
+	}
+	return SC.Help + "\n\n" + help
+}
+
+// CommandArgs returns the Spec, a nil Spec takes no arguments.
+func (SC *SpecCommand) CommandArgs() *ArgSpec {
+	if SC.Spec == nil {
+		return &ArgSpec{}
+	}
+	return SC.Spec
+}
+
+func (SC *SpecCommand) CommandComplete(args []string) []string {
+	if SC.Complete == nil {
+		return nil
+	}
+	return SC.Complete(args)
+}
+
+func (SC *SpecCommand) CommandCallback(args []string, context map[string]interface{}) {
+	A := ParsedArgs(context)
+	if A == nil {
+		var err error
+		A, err = SC.CommandArgs().Parse(args)
+		if err != nil {
+			go events.SendCustomEvent("/user/error", fmt.Sprintf("%s: %v\nusage: %s", SC.Name, err, SC.CommandUsage()))
+			return
+		}
+	}
+	SC.Callback(A, context)
+}
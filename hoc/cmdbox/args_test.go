@@ -0,0 +1,150 @@
+package cmdbox
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		input string
+		words []string
+		err   bool
+	}{
+		{"", []string{}, false},
+		{"  open   file  ", []string{"open", "file"}, false},
+		{`echo "hello world"`, []string{"echo", "hello world"}, false},
+		{`echo 'a "b" c'`, []string{"echo", `a "b" c`}, false},
+		{`echo "a \"b\" c"`, []string{"echo", `a "b" c`}, false},
+		{`echo a\ b`, []string{"echo", "a b"}, false},
+		{`echo '' x`, []string{"echo", "", "x"}, false},
+		{`echo it'"s'`, []string{"echo", `it"s`}, false},
+		{`echo "open`, nil, true},
+		{`echo open\`, nil, true},
+	}
+
+	for _, test := range tests {
+		words, err := Tokenize(test.input)
+		if test.err {
+			if err == nil {
+				t.Errorf("Tokenize(%q): expected an error, got %q", test.input, words)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Tokenize(%q): unexpected error: %v", test.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(words, test.words) {
+			t.Errorf("Tokenize(%q): expected %q, got %q", test.input, test.words, words)
+		}
+
+		// quoting gives the words back
+		again, err := Tokenize(Join(words))
+		if err != nil || !reflect.DeepEqual(again, words) {
+			t.Errorf("Tokenize(Join(%q)): got %q, %v", words, again, err)
+		}
+	}
+}
+
+func TestArgSpecParse(t *testing.T) {
+	spec := &ArgSpec{
+		Args: []Arg{
+			{Name: "name", Required: true},
+			{Name: "count", Type: IntArg, Default: 1},
+		},
+		Flags: []Flag{
+			{Name: "verbose", Short: "v", Type: BoolArg},
+			{Name: "timeout", Type: DurationArg, Default: time.Second},
+		},
+	}
+
+	A, err := spec.Parse([]string{"-v", "job", "--timeout=5s", "-3"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if A.String("name") != "job" || A.Int("count") != -3 || !A.Bool("verbose") || A.Duration("timeout") != 5*time.Second {
+		t.Errorf("unexpected values: %v", A.Values)
+	}
+
+	A, err = spec.Parse([]string{"job"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if A.Int("count") != 1 || A.Bool("verbose") || A.Duration("timeout") != time.Second {
+		t.Errorf("defaults not applied: %v", A.Values)
+	}
+
+	bad := [][]string{
+		{},
+		{"job", "many"},
+		{"job", "1", "extra"},
+		{"--nope", "job"},
+		{"job", "--timeout"},
+	}
+	for _, args := range bad {
+		if _, err := spec.Parse(args); err == nil {
+			t.Errorf("Parse(%q): expected an error", args)
+		}
+	}
+
+	if usage := spec.Usage("run"); usage != "run [-v|--verbose] [--timeout duration] <name> [count]" {
+		t.Errorf("unexpected usage: %q", usage)
+	}
+}
+
+func TestSpecCommandNilSpec(t *testing.T) {
+	calls := 0
+	SC := &SpecCommand{
+		Name: "ping",
+		Callback: func(A *Args, context map[string]interface{}) {
+			calls++
+		},
+	}
+
+	if usage := SC.CommandUsage(); usage != "ping" {
+		t.Errorf("unexpected usage: %q", usage)
+	}
+	SC.CommandCallback(nil, map[string]interface{}{})
+	if calls != 1 {
+		t.Errorf("callback not called without arguments")
+	}
+	SC.CommandCallback([]string{"extra"}, map[string]interface{}{})
+	if calls != 1 {
+		t.Errorf("callback called despite a parse error")
+	}
+}
+
+func TestLexExpand(t *testing.T) {
+	vars := map[string]string{"x": "a b", "y": "|"}
+	expand := func(text string) string {
+		text = strings.Replace(text, "$$", "\x00", -1)
+		text = os.Expand(text, func(name string) string { return vars[name] })
+		return strings.Replace(text, "\x00", "$", -1)
+	}
+
+	tests := []struct {
+		input string
+		words []string
+	}{
+		{"echo $x", []string{"echo", "a b"}},
+		{`echo "$x!" '$x' \$x`, []string{"echo", "a b!", "$x", "$x"}},
+		{`echo ${x}'$x'"$x"`, []string{"echo", "a b$xa b"}},
+		{"$nope", []string{""}},
+		{"echo $y grep", []string{"echo", "|", "grep"}},
+	}
+
+	for _, test := range tests {
+		words, _, err := lex(test.input, true, expand)
+		if err != nil {
+			t.Errorf("lex(%q): unexpected error: %v", test.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(words, test.words) {
+			t.Errorf("lex(%q): expected %q, got %q", test.input, test.words, words)
+		}
+	}
+}
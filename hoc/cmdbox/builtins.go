@@ -0,0 +1,380 @@
+package cmdbox
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell"
+	"github.com/pkg/errors"
+	"github.com/verdverm/tview"
+	"github.com/verdverm/vermui"
+	"github.com/verdverm/vermui/events"
+)
+
+const (
+	popupOverlay  = "cmdbox-popup"
+	maxAliasDepth = 16
+)
+
+// addBuiltins registers the commands every command box has.
+func (CB *CmdBoxWidget) addBuiltins() {
+	CB.commands["help"] = &DefaultCommand{
+		Name:     "help",
+		Usage:    "help [command]",
+		Help:     "list the commands, or show the usage and help of one",
+		Callback: CB.helpCommand,
+		Complete: func(args []string) []string {
+			if len(args) > 1 {
+				return nil
+			}
+			return CB.commandNames()
+		},
+	}
+
+	CB.commands["history"] = &DefaultCommand{
+		Name:     "history",
+		Usage:    "history",
+		Help:     "list the previous inputs, newest last",
+		Callback: CB.historyCommand,
+	}
+
+	CB.commands["jobs"] = &DefaultCommand{
+		Name:     "jobs",
+		Usage:    "jobs",
+		Help:     "list the running and recently finished commands",
+		Callback: CB.jobsCommand,
+	}
+
+	CB.commands["kill"] = &DefaultCommand{
+		Name:     "kill",
+		Usage:    "kill [job-id]",
+		Help:     "cancel a running command, the newest one if no id is given",
+		Callback: CB.killCommand,
+	}
+
+	CB.commands["back"] = &DefaultCommand{
+		Name:  "back",
+		Usage: "back",
+		Help:  "go back to the previous route",
+		Callback: func(args []string, context map[string]interface{}) {
+			go events.SendCustomEvent("/router/back", nil)
+		},
+	}
+
+	CB.commands["forward"] = &DefaultCommand{
+		Name:  "forward",
+		Usage: "forward",
+		Help:  "go forward again, after going back",
+		Callback: func(args []string, context map[string]interface{}) {
+			go events.SendCustomEvent("/router/forward", nil)
+		},
+	}
+
+	CB.commands["source"] = &DefaultCommand{
+		Name:     "source",
+		Usage:    "source <file>",
+		Help:     "run the commands in a file, one per line, '#' starts a comment and $name expands a variable",
+		Callback: CB.sourceCommand,
+	}
+
+	CB.commands["set"] = &DefaultCommand{
+		Name:     "set",
+		Usage:    "set [name = value]",
+		Help:     "list the variables, or set one for use as $name in scripts",
+		Callback: CB.setCommand,
+	}
+
+	CB.commands["echo"] = &DefaultCommand{
+		Name:     "echo",
+		Usage:    "echo [word...]",
+		Help:     "write the words, for pipelines and redirects",
+		Callback: CB.echoCommand,
+	}
+
+	CB.commands["grep"] = &DefaultCommand{
+		Name:     "grep",
+		Usage:    "grep [-v] <regexp>",
+		Help:     "write the input lines matching regexp, or not matching with -v",
+		Callback: CB.grepCommand,
+	}
+
+	CB.commands["alias"] = &DefaultCommand{
+		Name:     "alias",
+		Usage:    "alias [name [= expansion]]",
+		Help:     "list, show or define aliases, an alias is replaced by its expansion when it is the first word of the input",
+		Callback: CB.aliasCommand,
+	}
+
+	CB.commands["unalias"] = &DefaultCommand{
+		Name:     "unalias",
+		Usage:    "unalias <name>",
+		Help:     "remove an alias",
+		Callback: CB.unaliasCommand,
+		Complete: func(args []string) []string {
+			CB.Lock()
+			defer CB.Unlock()
+			names := []string{}
+			for name := range CB.aliases {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return names
+		},
+	}
+}
+
+func (CB *CmdBoxWidget) commandNames() []string {
+	CB.Lock()
+	defer CB.Unlock()
+
+	names := make([]string, 0, len(CB.commands))
+	for name := range CB.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (CB *CmdBoxWidget) helpCommand(args []string, context map[string]interface{}) {
+	if len(args) == 0 {
+		lines := []string{}
+		for _, name := range CB.commandNames() {
+			CB.Lock()
+			cmd := CB.commands[name]
+			CB.Unlock()
+			lines = append(lines, fmt.Sprintf("%-12s %s", name, cmd.CommandUsage()))
+		}
+		lines = append(lines, "", "'help <command>' for more, '/path' to navigate, '!cmd' to run a shell command")
+		CB.Popup("help", strings.Join(lines, "\n"))
+		return
+	}
+
+	name := strings.ToLower(args[0])
+	CB.Lock()
+	cmd, ok := CB.commands[name]
+	CB.Unlock()
+	if !ok {
+		go events.SendCustomEvent("/user/error", fmt.Sprintf("help: unknown command %q", name))
+		return
+	}
+	CB.Popup("help "+name, "usage: "+cmd.CommandUsage()+"\n\n"+cmd.CommandHelp())
+}
+
+func (CB *CmdBoxWidget) historyCommand(args []string, context map[string]interface{}) {
+	CB.Lock()
+	lines := make([]string, len(CB.history))
+	for i, h := range CB.history {
+		lines[i] = fmt.Sprintf("%4d  %s", i+1, h)
+	}
+	CB.Unlock()
+
+	CB.Popup("history", strings.Join(lines, "\n"))
+}
+
+func (CB *CmdBoxWidget) jobsCommand(args []string, context map[string]interface{}) {
+	self := ExecFromContext(context)
+	lines := []string{}
+	for _, ex := range CB.Jobs() {
+		if ex != self {
+			lines = append(lines, ex.String())
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "no jobs")
+	}
+	CB.Popup("jobs", strings.Join(lines, "\n"))
+}
+
+func (CB *CmdBoxWidget) killCommand(args []string, context map[string]interface{}) {
+	id := 0
+	if len(args) > 0 {
+		var err error
+		if id, err = strconv.Atoi(strings.TrimPrefix(args[0], "#")); err != nil {
+			go events.SendCustomEvent("/user/error", fmt.Sprintf("kill: bad job id %q", args[0]))
+			return
+		}
+	}
+
+	// don't count ourselves as the newest running command
+	if self := ExecFromContext(context); self != nil && id == 0 {
+		for _, ex := range CB.Jobs() {
+			if ex != self && ex.Running() {
+				id = ex.Id
+			}
+		}
+		if id == 0 {
+			go events.SendCustomEvent("/user/error", "kill: no running commands")
+			return
+		}
+	}
+
+	if !CB.Kill(id) {
+		go events.SendCustomEvent("/user/error", fmt.Sprintf("kill: no running job %d", id))
+		return
+	}
+	go events.SendCustomEvent("/status/message", fmt.Sprintf("cancelled job %d", id))
+}
+
+func (CB *CmdBoxWidget) aliasCommand(args []string, context map[string]interface{}) {
+	// list them all
+	if len(args) == 0 {
+		CB.Lock()
+		lines := []string{}
+		for name, exp := range CB.aliases {
+			lines = append(lines, fmt.Sprintf("%s = %s", name, exp))
+		}
+		CB.Unlock()
+		sort.Strings(lines)
+		CB.Popup("aliases", strings.Join(lines, "\n"))
+		return
+	}
+
+	def := Join(args)
+	i := strings.Index(def, "=")
+
+	// show one
+	if i < 0 {
+		name := strings.ToLower(args[0])
+		CB.Lock()
+		exp, ok := CB.aliases[name]
+		CB.Unlock()
+		if !ok {
+			go events.SendCustomEvent("/user/error", fmt.Sprintf("alias: %q is not defined", name))
+			return
+		}
+		go events.SendCustomEvent("/status/message", fmt.Sprintf("%s = %s", name, exp))
+		return
+	}
+
+	// define one
+	name := strings.ToLower(strings.TrimSpace(def[:i]))
+	exp := strings.TrimSpace(def[i+1:])
+	if name == "" || strings.ContainsAny(name, " \t/") || exp == "" {
+		go events.SendCustomEvent("/user/error", "usage: alias name = expansion")
+		return
+	}
+	CB.Lock()
+	CB.aliases[name] = exp
+	CB.Unlock()
+
+	CB.saveAliases()
+	go events.SendCustomEvent("/status/message", fmt.Sprintf("alias %s = %s", name, exp))
+}
+
+func (CB *CmdBoxWidget) unaliasCommand(args []string, context map[string]interface{}) {
+	if len(args) != 1 {
+		go events.SendCustomEvent("/user/error", "usage: unalias <name>")
+		return
+	}
+	CB.Lock()
+	delete(CB.aliases, strings.ToLower(args[0]))
+	CB.Unlock()
+
+	CB.saveAliases()
+}
+
+// expandAlias replaces an alias in the command position with its expansion.
+func (CB *CmdBoxWidget) expandAlias(command string, args []string) (string, []string, error) {
+	seen := map[string]bool{}
+	for depth := 0; depth < maxAliasDepth; depth++ {
+		CB.Lock()
+		exp, ok := CB.aliases[strings.ToLower(command)]
+		CB.Unlock()
+		if !ok {
+			return command, args, nil
+		}
+		if seen[command] {
+			return command, args, fmt.Errorf("alias %q expands to itself", command)
+		}
+		seen[command] = true
+
+		flds, err := Tokenize(exp)
+		if err != nil {
+			return command, args, errors.Wrapf(err, "in alias %q", command)
+		}
+		if len(flds) == 0 {
+			return command, args, nil
+		}
+		command, args = flds[0], append(flds[1:], args...)
+	}
+	return command, args, fmt.Errorf("alias %q is nested too deep", command)
+}
+
+// SetAliasFile loads aliases from path and saves them there on every
+// change, so they persist across sessions. A missing file is fine.
+func (CB *CmdBoxWidget) SetAliasFile(path string) error {
+	CB.Lock()
+	CB.aliasFile = path
+	CB.Unlock()
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "in SetAliasFile")
+	}
+	defer f.Close()
+
+	CB.Lock()
+	defer CB.Unlock()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		i := strings.Index(line, "=")
+		if i < 0 {
+			continue
+		}
+		CB.aliases[strings.TrimSpace(line[:i])] = strings.TrimSpace(line[i+1:])
+	}
+	return errors.Wrap(scanner.Err(), "in SetAliasFile")
+}
+
+func (CB *CmdBoxWidget) saveAliases() {
+	CB.Lock()
+	path := CB.aliasFile
+	lines := []string{}
+	for name, exp := range CB.aliases {
+		lines = append(lines, name+" = "+exp)
+	}
+	CB.Unlock()
+
+	if path == "" {
+		return
+	}
+	sort.Strings(lines)
+	data := strings.Join(lines, "\n") + "\n"
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		go events.SendCustomEvent("/console/error", errors.Wrap(err, "saving aliases"))
+	}
+}
+
+// Popup shows text in a centered overlay, focused until a key closes it.
+func (CB *CmdBoxWidget) Popup(title, text string) {
+	width, height := len(title)+6, 2
+	for _, line := range strings.Split(text, "\n") {
+		if len(line)+4 > width {
+			width = len(line) + 4
+		}
+		height++
+	}
+
+	tv := tview.NewTextView().SetScrollable(true).SetWrap(false)
+	tv.SetTitle(" " + title + " ").SetBorder(true)
+	fmt.Fprint(tv, text)
+	tv.SetDoneFunc(func(key tcell.Key) {
+		vermui.HideOverlay(popupOverlay)
+		vermui.Unfocus()
+	})
+
+	vermui.ShowOverlay(popupOverlay, tv, vermui.Centered(width, height))
+	vermui.SetFocus(tv)
+}
@@ -0,0 +1,53 @@
+package cmdbox
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/verdverm/vermui"
+	"github.com/verdverm/vermui/events"
+)
+
+func (CB *CmdBoxWidget) addClipboardCommands() {
+	CB.commands["copy"] = &DefaultCommand{
+		Name:     "copy",
+		Usage:    "copy [word...]",
+		Help:     "put the words, or the input piped in, on the clipboard",
+		Callback: CB.copyCommand,
+	}
+
+	CB.commands["paste"] = &DefaultCommand{
+		Name:     "paste",
+		Usage:    "paste",
+		Help:     "write the clipboard, for pipelines and redirects",
+		Callback: CB.pasteCommand,
+	}
+}
+
+func (CB *CmdBoxWidget) copyCommand(args []string, context map[string]interface{}) {
+	text := strings.Join(args, " ")
+	if len(args) == 0 {
+		if ex := ExecFromContext(context); ex != nil {
+			data, err := ioutil.ReadAll(ex.In)
+			if err != nil {
+				go events.SendCustomEvent("/user/error", fmt.Sprintf("copy: %v", err))
+				return
+			}
+			text = strings.TrimSuffix(string(data), "\n")
+		}
+	}
+	if text == "" {
+		go events.SendCustomEvent("/user/error", "copy: nothing to copy")
+		return
+	}
+	if err := vermui.Copy(text); err != nil {
+		go events.SendCustomEvent("/console/warn", fmt.Sprintf("clipboard: %v", err))
+	}
+}
+
+func (CB *CmdBoxWidget) pasteCommand(args []string, context map[string]interface{}) {
+	if ex := ExecFromContext(context); ex != nil {
+		fmt.Fprintln(ex.Out, vermui.ClipboardText())
+	}
+}
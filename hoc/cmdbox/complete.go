@@ -0,0 +1,198 @@
+package cmdbox
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/verdverm/tview"
+	"github.com/verdverm/vermui"
+)
+
+const (
+	completionOverlay = "cmdbox-completion"
+	maxCompletions    = 10
+)
+
+// Completer is an optional interface for Commands which can complete
+// their arguments. args are the arguments typed so far, the last one
+// is the (possibly empty) word being completed.
+type Completer interface {
+	CommandComplete(args []string) []string
+}
+
+// completion is the state of a Tab cycle.
+type completion struct {
+	head  string   // input before the word being completed
+	cands []string // candidates for the word
+	idx   int      // current candidate
+	text  string   // the input after applying the current candidate
+}
+
+// SetPathCompleter sets the function used to complete "/"-prefixed input,
+// typically the Router's CompletePath.
+func (CB *CmdBoxWidget) SetPathCompleter(f func(prefix string) []string) {
+	CB.Lock()
+	defer CB.Unlock()
+	CB.pathCompleter = f
+}
+
+// candidates returns the completions for the last word of the input
+// and the input before it.
+func (CB *CmdBoxWidget) candidates(input string) (head string, word string, cands []string) {
+	i := strings.LastIndexAny(input, " \t")
+	head, word = input[:i+1], input[i+1:]
+
+	CB.Lock()
+	pathCompleter := CB.pathCompleter
+	names := make([]string, 0, len(CB.commands))
+	for name := range CB.commands {
+		names = append(names, name)
+	}
+	CB.Unlock()
+
+	// a new command starts after a '|', an output name follows a '>'
+	stage := head
+	if j := strings.LastIndexAny(head, "|>"); j >= 0 {
+		stage = head[j+1:]
+		if head[j] == '>' {
+			if strings.TrimSpace(stage) != "" {
+				return head, word, nil
+			}
+			for _, name := range CB.Outputs() {
+				if strings.HasPrefix(name, word) {
+					cands = append(cands, name)
+				}
+			}
+			return head, word, cands
+		}
+	}
+
+	// the command name, or a path
+	if strings.TrimSpace(stage) == "" {
+		if strings.HasPrefix(word, "/") {
+			if pathCompleter != nil {
+				cands = pathCompleter(word)
+			}
+			return head, word, cands
+		}
+		for _, name := range names {
+			if strings.HasPrefix(name, strings.ToLower(word)) {
+				cands = append(cands, name+" ")
+			}
+		}
+		sort.Strings(cands)
+		return head, word, cands
+	}
+
+	// the arguments of a command
+	flds := strings.Fields(stage)
+	CB.Lock()
+	cmd, ok := CB.commands[strings.ToLower(flds[0])]
+	CB.Unlock()
+	if !ok {
+		return head, word, nil
+	}
+	c, ok := cmd.(Completer)
+	if !ok {
+		return head, word, nil
+	}
+	args := append(flds[1:], word)
+	for _, cand := range c.CommandComplete(args) {
+		if strings.HasPrefix(cand, word) {
+			cands = append(cands, cand)
+		}
+	}
+	return head, word, cands
+}
+
+// complete handles Tab (dir = 1) and Backtab (dir = -1).
+// Repeated presses cycle through the candidates.
+func (CB *CmdBoxWidget) complete(dir int) {
+	input := CB.GetText()
+
+	if comp := CB.comp; comp != nil && comp.text == input {
+		comp.idx = (comp.idx + dir + len(comp.cands)) % len(comp.cands)
+		CB.applyCompletion()
+		return
+	}
+
+	head, word, cands := CB.candidates(input)
+	switch len(cands) {
+	case 0:
+		CB.endCompletion()
+		return
+	case 1:
+		CB.endCompletion()
+		CB.SetText(head + cands[0])
+		return
+	}
+
+	// extend to the common prefix first, then cycle
+	if prefix := commonPrefix(cands); len(prefix) > len(word) {
+		CB.endCompletion()
+		CB.SetText(head + prefix)
+		return
+	}
+
+	idx := 0
+	if dir < 0 {
+		idx = len(cands) - 1
+	}
+	CB.comp = &completion{
+		head:  head,
+		cands: cands,
+		idx:   idx,
+	}
+	CB.applyCompletion()
+}
+
+func (CB *CmdBoxWidget) applyCompletion() {
+	comp := CB.comp
+	comp.text = comp.head + comp.cands[comp.idx]
+	CB.SetText(comp.text)
+
+	// popup list under the input
+	list := tview.NewTextView().SetDynamicColors(true).SetWrap(false)
+	list.SetBorder(true)
+	width := 0
+	for i, cand := range comp.cands {
+		if len(cand)+4 > width {
+			width = len(cand) + 4
+		}
+		if i == comp.idx {
+			fmt.Fprintf(list, "[::r]%s[::-]\n", cand)
+		} else {
+			fmt.Fprintln(list, cand)
+		}
+	}
+	list.ScrollTo(comp.idx, 0)
+	_, _, w, _ := CB.GetRect()
+	if width > w {
+		width = w
+	}
+	height := len(comp.cands)
+	if height > maxCompletions {
+		height = maxCompletions
+	}
+	vermui.ShowOverlay(completionOverlay, list, vermui.Below(CB, width, height+2))
+}
+
+// endCompletion drops the cycle state and the popup.
+func (CB *CmdBoxWidget) endCompletion() {
+	if CB.comp == nil {
+		return
+	}
+	CB.comp = nil
+	vermui.HideOverlay(completionOverlay)
+}
+
+func commonPrefix(strs []string) string {
+	prefix := strs[0]
+	for _, s := range strs[1:] {
+		for !strings.HasPrefix(s, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
@@ -0,0 +1,319 @@
+package cmdbox
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/verdverm/vermui/events"
+)
+
+// maxFinished is how many finished executions are kept for "jobs".
+const maxFinished = 20
+
+// ExecCommand is an optional interface for Commands which run with a
+// context.Context that is cancelled by Escape or "kill", and report
+// progress, output and a final error through the Execution.
+type ExecCommand interface {
+	Command
+	CommandExec(ctx context.Context, ex *Execution) error
+}
+
+// Execution is one run of a command. Plain Commands find it,
+// and its context, in their context map under "exec" and "ctx".
+type Execution struct {
+	Id      int
+	Command string
+	Args    []string
+	Started time.Time
+
+	// In is the output of the previous command in a pipeline, empty
+	// otherwise. Out is where the command writes, the next command,
+	// a redirect, or the console.
+	In  io.Reader
+	Out io.Writer
+
+	out *outputWriter
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu       sync.Mutex
+	progress float64
+	status   string
+	output   []string
+	err      error
+	finished time.Time
+}
+
+func newExecution(parent context.Context, id int, command string, args []string) *Execution {
+	ctx, cancel := context.WithCancel(parent)
+	return &Execution{
+		Id:      id,
+		Command: command,
+		Args:    args,
+		Started: time.Now(),
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+}
+
+// ExecFromContext returns the Execution the CmdBoxWidget put in a callback's context.
+func ExecFromContext(context map[string]interface{}) *Execution {
+	if ex, ok := context["exec"].(*Execution); ok {
+		return ex
+	}
+	return nil
+}
+
+func (E *Execution) Context() context.Context {
+	return E.ctx
+}
+
+// Cancel asks the command to stop by cancelling its context.
+func (E *Execution) Cancel() {
+	E.cancel()
+}
+
+// Done is closed when the command has returned.
+func (E *Execution) Done() <-chan struct{} {
+	return E.done
+}
+
+func (E *Execution) Running() bool {
+	select {
+	case <-E.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Progress reports how far along the command is, fraction is in [0,1].
+func (E *Execution) Progress(fraction float64, status string) {
+	E.mu.Lock()
+	E.progress = fraction
+	E.status = status
+	E.mu.Unlock()
+
+	go events.SendCustomEvent("/cmdbox/job/progress", E)
+}
+
+// Println writes a line to Out.
+func (E *Execution) Println(a ...interface{}) {
+	fmt.Fprintln(E.Out, a...)
+}
+
+func (E *Execution) Printf(format string, a ...interface{}) {
+	E.Println(fmt.Sprintf(format, a...))
+}
+
+// addOutput records a line written to Out.
+func (E *Execution) addOutput(line string, toConsole bool) {
+	E.mu.Lock()
+	E.output = append(E.output, line)
+	E.mu.Unlock()
+
+	events.SendCustomEvent("/cmdbox/job/output", E)
+	if toConsole {
+		events.SendCustomEvent("/console/info", fmt.Sprintf("[%s#%d] %s", E.Command, E.Id, line))
+	}
+}
+
+// Output returns the lines written to Out so far.
+func (E *Execution) Output() []string {
+	E.mu.Lock()
+	defer E.mu.Unlock()
+	return append([]string{}, E.output...)
+}
+
+// Err is the error the command finished with.
+func (E *Execution) Err() error {
+	E.mu.Lock()
+	defer E.mu.Unlock()
+	return E.err
+}
+
+// Status returns the last reported progress.
+func (E *Execution) Status() (float64, string) {
+	E.mu.Lock()
+	defer E.mu.Unlock()
+	return E.progress, E.status
+}
+
+// Elapsed is the run time, so far or in total.
+func (E *Execution) Elapsed() time.Duration {
+	E.mu.Lock()
+	defer E.mu.Unlock()
+	if E.finished.IsZero() {
+		return time.Since(E.Started)
+	}
+	return E.finished.Sub(E.Started)
+}
+
+func (E *Execution) String() string {
+	state := "running"
+	if !E.Running() {
+		state = "done"
+		if err := E.Err(); err != nil {
+			state = "error: " + err.Error()
+		}
+	} else if p, s := E.Status(); s != "" || p > 0 {
+		state = fmt.Sprintf("running %3.0f%% %s", p*100, s)
+	}
+	return fmt.Sprintf("%4d  %-24s %8s  %s", E.Id, strings.TrimSpace(E.Command+" "+Join(E.Args)),
+		E.Elapsed().Round(time.Second), state)
+}
+
+func (E *Execution) finish(err error) {
+	E.out.flush()
+
+	E.mu.Lock()
+	E.err = err
+	E.finished = time.Now()
+	E.mu.Unlock()
+
+	E.cancel()
+	close(E.done)
+}
+
+// outputWriter records the lines a command writes and passes
+// them on, to the console if there is nowhere else.
+type outputWriter struct {
+	E    *Execution
+	dest io.Writer
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (W *outputWriter) Write(p []byte) (int, error) {
+	if W.dest != nil {
+		if _, err := W.dest.Write(p); err != nil {
+			return 0, err
+		}
+	}
+
+	W.mu.Lock()
+	W.buf.Write(p)
+	lines := []string{}
+	for {
+		i := bytes.IndexByte(W.buf.Bytes(), '\n')
+		if i < 0 {
+			break
+		}
+		lines = append(lines, string(W.buf.Next(i + 1)[:i]))
+	}
+	W.mu.Unlock()
+
+	for _, line := range lines {
+		W.E.addOutput(line, W.dest == nil)
+	}
+	return len(p), nil
+}
+
+// flush records a last line without a newline.
+func (W *outputWriter) flush() {
+	W.mu.Lock()
+	line := W.buf.String()
+	W.buf.Reset()
+	W.mu.Unlock()
+
+	if line != "" {
+		W.E.addOutput(line, W.dest == nil)
+	}
+}
+
+// execute runs cmd as a tracked job, reading in and writing to out,
+// either may be nil for no input and output to the console.
+func (CB *CmdBoxWidget) execute(ctx context.Context, cmd Command, command string, args []string, cmdContext map[string]interface{}, in io.Reader, out io.Writer) *Execution {
+	CB.Lock()
+	CB.jobId++
+	ex := newExecution(ctx, CB.jobId, command, args)
+	CB.jobs = append(CB.jobs, ex)
+	CB.Unlock()
+
+	if in == nil {
+		in = strings.NewReader("")
+	}
+	ex.In = in
+	ex.out = &outputWriter{E: ex, dest: out}
+	ex.Out = ex.out
+
+	cmdContext["ctx"] = ex.ctx
+	cmdContext["exec"] = ex
+
+	go events.SendCustomEvent("/cmdbox/job/start", ex)
+
+	go func() {
+		var err error
+		if ec, ok := cmd.(ExecCommand); ok {
+			err = ec.CommandExec(ex.ctx, ex)
+		} else {
+			cmd.CommandCallback(args, cmdContext)
+		}
+		ex.finish(err)
+		CB.reapJobs()
+
+		if err != nil && err != context.Canceled {
+			events.SendCustomEvent("/user/error", fmt.Sprintf("%s: %v", command, err))
+		}
+		events.SendCustomEvent("/cmdbox/job/done", ex)
+	}()
+
+	return ex
+}
+
+// reapJobs drops the oldest finished jobs past the limit.
+func (CB *CmdBoxWidget) reapJobs() {
+	CB.Lock()
+	defer CB.Unlock()
+
+	finished := 0
+	for _, ex := range CB.jobs {
+		if !ex.Running() {
+			finished++
+		}
+	}
+	jobs := CB.jobs[:0]
+	for _, ex := range CB.jobs {
+		if !ex.Running() && finished > maxFinished {
+			finished--
+			continue
+		}
+		jobs = append(jobs, ex)
+	}
+	CB.jobs = jobs
+}
+
+// Jobs returns the running and recently finished executions, oldest first.
+func (CB *CmdBoxWidget) Jobs() []*Execution {
+	CB.Lock()
+	defer CB.Unlock()
+
+	jobs := append([]*Execution{}, CB.jobs...)
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].Id < jobs[j].Id
+	})
+	return jobs
+}
+
+// Kill cancels the job with the id, or the newest running job if id is 0.
+func (CB *CmdBoxWidget) Kill(id int) bool {
+	jobs := CB.Jobs()
+	for i := len(jobs) - 1; i >= 0; i-- {
+		ex := jobs[i]
+		if (id == 0 || ex.Id == id) && ex.Running() {
+			ex.Cancel()
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,220 @@
+package cmdbox
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/gdamore/tcell"
+	"github.com/pkg/errors"
+	"github.com/verdverm/vermui/events"
+)
+
+// DefaultHistoryLimit is the history size when none is given.
+const DefaultHistoryLimit = 1000
+
+// SetHistoryFile loads the history from path and saves it there after
+// every input, keeping at most limit entries (0 for the default).
+// A missing file is fine, it is created on the first save.
+func (CB *CmdBoxWidget) SetHistoryFile(path string, limit int) error {
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	}
+
+	CB.Lock()
+	CB.historyFile = path
+	CB.historyLimit = limit
+	CB.Unlock()
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "in SetHistoryFile")
+	}
+	defer f.Close()
+
+	loaded := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			loaded = append(loaded, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Wrap(err, "in SetHistoryFile")
+	}
+
+	CB.Lock()
+	for _, line := range loaded {
+		CB.pushHistory(line)
+	}
+	CB.hIdx = len(CB.history)
+	CB.Unlock()
+
+	return nil
+}
+
+// addHistory records an input and saves the history file.
+func (CB *CmdBoxWidget) addHistory(input string) {
+	CB.Lock()
+	CB.pushHistory(input)
+	path := CB.historyFile
+	lines := append([]string{}, CB.history...)
+	CB.Unlock()
+
+	if path == "" {
+		return
+	}
+	data := strings.Join(lines, "\n") + "\n"
+	if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+		go events.SendCustomEvent("/console/error", errors.Wrap(err, "saving history"))
+	}
+}
+
+// pushHistory appends input, dropping an earlier copy of it
+// and the oldest entries past the limit. CB must be locked.
+func (CB *CmdBoxWidget) pushHistory(input string) {
+	for i, h := range CB.history {
+		if h == input {
+			CB.history = append(CB.history[:i], CB.history[i+1:]...)
+			break
+		}
+	}
+	CB.history = append(CB.history, input)
+
+	limit := CB.historyLimit
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	}
+	if over := len(CB.history) - limit; over > 0 {
+		CB.history = append([]string{}, CB.history[over:]...)
+	}
+}
+
+// histSearch is the state of a Ctrl-R reverse incremental search.
+type histSearch struct {
+	query string
+	idx   int    // history index of the match, -1 if none
+	text  string // input before the search started
+	label string // label before the search started
+
+	// Ctrl-R found no older match, idx is still the last one
+	failing bool
+}
+
+func (CB *CmdBoxWidget) startSearch() {
+	CB.search = &histSearch{
+		idx:   -1,
+		text:  CB.GetText(),
+		label: CB.GetLabel(),
+	}
+	CB.SetText("")
+	CB.showSearch()
+}
+
+// findSearch looks for the query in entries older than from.
+func (CB *CmdBoxWidget) findSearch(from int) bool {
+	S := CB.search
+	if from > len(CB.history) {
+		from = len(CB.history)
+	}
+	for i := from - 1; i >= 0; i-- {
+		if strings.Contains(CB.history[i], S.query) {
+			S.idx = i
+			return true
+		}
+	}
+	return false
+}
+
+func (CB *CmdBoxWidget) showSearch() {
+	S := CB.search
+	prompt := "reverse-i-search"
+	match := ""
+	if S.idx >= 0 {
+		h := CB.history[S.idx]
+		if i := strings.Index(h, S.query); i >= 0 && S.query != "" {
+			match = escapeTags(h[:i]) + "[black:yellow]" + escapeTags(S.query) + "[-:-]" + escapeTags(h[i+len(S.query):])
+		} else {
+			match = escapeTags(h)
+		}
+	}
+	if S.failing || (S.idx < 0 && S.query != "") {
+		prompt = "failing reverse-i-search"
+	}
+	CB.SetLabel(fmt.Sprintf("(%s)`%s': %s", prompt, escapeTags(S.query), match))
+}
+
+// endSearch leaves search mode, keeping the match if accept is true.
+func (CB *CmdBoxWidget) endSearch(accept bool) {
+	S := CB.search
+	CB.search = nil
+	CB.SetLabel(S.label)
+	if accept && S.idx >= 0 {
+		CB.SetText(CB.history[S.idx])
+		CB.hIdx = len(CB.history)
+	} else {
+		CB.SetText(S.text)
+	}
+}
+
+// searchKey handles a key while searching,
+// returning false if it should be processed as usual.
+func (CB *CmdBoxWidget) searchKey(evt *tcell.EventKey) bool {
+	S := CB.search
+
+	switch evt.Key() {
+	case tcell.KeyRune:
+		S.failing = false
+		S.query += string(evt.Rune())
+		from := len(CB.history)
+		if S.idx >= 0 {
+			// stay on the current match if it still matches
+			from = S.idx + 1
+		}
+		if !CB.findSearch(from) {
+			S.idx = -1
+		}
+
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		S.failing = false
+		if len(S.query) > 0 {
+			r := []rune(S.query)
+			S.query = string(r[:len(r)-1])
+		}
+		if !CB.findSearch(len(CB.history)) {
+			S.idx = -1
+		}
+
+	case tcell.KeyCtrlR:
+		from := len(CB.history)
+		if S.idx >= 0 {
+			from = S.idx
+		}
+		S.failing = !CB.findSearch(from)
+
+	case tcell.KeyEscape, tcell.KeyCtrlG:
+		CB.endSearch(false)
+		return true
+
+	default:
+		// accept, then handle the key (e.g. Enter submits)
+		CB.endSearch(true)
+		return false
+	}
+
+	CB.showSearch()
+	return true
+}
+
+var tagPattern = regexp.MustCompile(`(\[[a-zA-Z0-9_,;: \-\."#]+\[*)\]`)
+
+// escapeTags keeps text from being read as color tags.
+func escapeTags(s string) string {
+	return tagPattern.ReplaceAllString(s, "$1[]")
+}
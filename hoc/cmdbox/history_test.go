@@ -0,0 +1,84 @@
+package cmdbox
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell"
+	"github.com/verdverm/tview"
+)
+
+func TestPushHistory(t *testing.T) {
+	tests := []struct {
+		limit  int
+		inputs []string
+		want   []string
+	}{
+		{0, []string{"a", "b", "c"}, []string{"a", "b", "c"}},
+		{0, []string{"a", "b", "a"}, []string{"b", "a"}},
+		{0, []string{"a", "a", "a"}, []string{"a"}},
+		{2, []string{"a", "b", "c"}, []string{"b", "c"}},
+		{2, []string{"a", "b", "a", "c"}, []string{"a", "c"}},
+		{3, []string{"a", "b", "c", "b", "d"}, []string{"c", "b", "d"}},
+		{1, []string{"a", "b"}, []string{"b"}},
+	}
+
+	for i, test := range tests {
+		CB := &CmdBoxWidget{history: []string{}, historyLimit: test.limit}
+		for _, input := range test.inputs {
+			CB.pushHistory(input)
+		}
+		if !reflect.DeepEqual(CB.history, test.want) {
+			t.Errorf("%d: pushing %q with limit %d: expected %q, got %q", i, test.inputs, test.limit, test.want, CB.history)
+		}
+	}
+}
+
+func TestSearchKey(t *testing.T) {
+	history := []string{"ls", "open foo", "echo hi", "open bar"}
+
+	// keys are typed as runes, but for '\x12' Ctrl-R and '\b' Backspace
+	tests := []struct {
+		keys    string
+		idx     int
+		failing bool
+	}{
+		{"", -1, false},
+		{"o", 3, false},
+		{"op", 3, false},
+		{"op\x12", 1, false},
+		{"op\x12\x12", 1, true},
+		{"op\x12\x12\x12", 1, true},
+		{"op\x12\x12e", 1, false},
+		{"open b\x12", 3, true},
+		{"ox", -1, true},
+		{"ox\b", 3, false},
+		{"e\x12", 2, false},
+		{"e\x12 h", -1, true},
+		{"\x12", 3, false},
+		{"zz\x12", -1, true},
+	}
+
+	for _, test := range tests {
+		CB := &CmdBoxWidget{InputField: tview.NewInputField(), history: history}
+		CB.startSearch()
+		for _, r := range test.keys {
+			evt := tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone)
+			switch r {
+			case '\x12':
+				evt = tcell.NewEventKey(tcell.KeyCtrlR, 0, tcell.ModCtrl)
+			case '\b':
+				evt = tcell.NewEventKey(tcell.KeyBackspace2, 0, tcell.ModNone)
+			}
+			if !CB.searchKey(evt) {
+				t.Fatalf("%q: searchKey(%q) was not handled", test.keys, r)
+			}
+		}
+		S := CB.search
+		failing := strings.HasPrefix(CB.GetLabel(), "(failing ")
+		if S.idx != test.idx || failing != test.failing {
+			t.Errorf("%q: expected match %d, failing %v, got %d, %v (%s)", test.keys, test.idx, test.failing, S.idx, failing, CB.GetLabel())
+		}
+	}
+}
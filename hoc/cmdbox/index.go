@@ -1,7 +1,9 @@
 package cmdbox
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/gdamore/tcell"
@@ -26,6 +28,9 @@ type DefaultCommand struct {
 	Help  string
 
 	Callback func(args []string, context map[string]interface{})
+
+	// Optional, completes the last of args, see Completer.
+	Complete func(args []string) []string
 }
 
 func (DC *DefaultCommand) CommandName() string {
@@ -40,6 +45,13 @@ func (DC *DefaultCommand) CommandUsage() string {
 	return DC.Usage
 }
 
+func (DC *DefaultCommand) CommandComplete(args []string) []string {
+	if DC.Complete == nil {
+		return nil
+	}
+	return DC.Complete(args)
+}
+
 func (DC *DefaultCommand) CommandCallback(args []string, context map[string]interface{}) {
 	DC.Callback(args, context)
 }
@@ -52,6 +64,25 @@ type CmdBoxWidget struct {
 	curr    string   // current input (potentially partial)
 	hIdx    int      // where we are in history
 	history []string // command history
+
+	comp          *completion           // current Tab cycle, if any
+	pathCompleter func(string) []string // completes "/path" input
+
+	jobs  []*Execution // running and recently finished commands
+	jobId int          // last job id handed out
+
+	aliases   map[string]string // name -> expansion
+	aliasFile string            // where aliases persist
+
+	historyFile  string      // where history persists
+	historyLimit int         // max history entries
+	search       *histSearch // Ctrl-R search, if active
+
+	outputs map[string]io.Writer // redirect targets, by name
+
+	vars   map[string]string // set by "set", expanded in scripts
+	rcFile string            // script run on the first Mount
+	rcDone bool
 }
 
 func New() *CmdBoxWidget {
@@ -59,7 +90,12 @@ func New() *CmdBoxWidget {
 		InputField: tview.NewInputField(),
 		commands:   make(map[string]Command),
 		history:    []string{},
+		aliases:    make(map[string]string),
+		vars:       make(map[string]string),
+		outputs:    make(map[string]io.Writer),
 	}
+	cb.addBuiltins()
+	cb.addClipboardCommands()
 
 	cb.InputField.
 		SetFieldBackgroundColor(tview.Styles.PrimitiveBackgroundColor).
@@ -96,38 +132,84 @@ func (CB *CmdBoxWidget) RemoveCommand(command Command) {
 	delete(CB.commands, command.CommandName())
 }
 
-func (CB *CmdBoxWidget) Mount(context map[string]interface{}) error {
-	vermui.AddWidgetHandler(CB, "/sys/key/C-<space>", func(e events.Event) {
+// Commands returns the registered commands, sorted by name.
+func (CB *CmdBoxWidget) Commands() []Command {
+	cmds := []Command{}
+	for _, name := range CB.commandNames() {
 		CB.Lock()
-		CB.curr = ""
-		CB.hIdx = len(CB.history)
+		cmds = append(cmds, CB.commands[name])
 		CB.Unlock()
+	}
+	return cmds
+}
+
+// Prefill focuses the command box with text to be completed by the user.
+func (CB *CmdBoxWidget) Prefill(text string) {
+	CB.Lock()
+	CB.curr = ""
+	CB.hIdx = len(CB.history)
+	CB.Unlock()
 
-		CB.SetText("")
-		CB.SetFieldTextColor(tcell.ColorWhite)
-		CB.SetBorderColor(tcell.Color69)
+	// validates, which colors the field
+	CB.SetText(text)
+	CB.validate(text)
 
-		vermui.SetFocus(CB.InputField)
+	vermui.SetFocus(CB)
+}
+
+func (CB *CmdBoxWidget) Mount(context map[string]interface{}) error {
+	vermui.AddWidgetHandler(CB, "/sys/key/C-<space>", func(e events.Event) {
+		CB.Prefill("")
+	})
+
+	CB.Lock()
+	rc := CB.rcFile
+	runRC := rc != "" && !CB.rcDone
+	CB.rcDone = true
+	CB.Unlock()
+	if runRC {
+		go CB.runRC(rc)
+	}
+
+	CB.SetChangedFunc(func(text string) {
+		// typing ends a Tab cycle
+		if CB.comp != nil && CB.comp.text != text {
+			CB.endCompletion()
+		}
+		CB.validate(text)
 	})
 
 	CB.SetFinishedFunc(func(key tcell.Key) {
+		if key != tcell.KeyTab && key != tcell.KeyBacktab {
+			CB.endCompletion()
+		}
+
 		switch key {
 		case tcell.KeyEnter:
 			input := CB.GetText()
 			input = strings.TrimSpace(input)
 			if input != "" {
-				flds := strings.Fields(input)
-				CB.Submit(flds[0], flds[1:])
+				if _, _, err := ParseLine(input); err != nil && input[0] != '!' {
+					go events.SendCustomEvent("/user/error", fmt.Sprintf("bad input: %v", err))
+					return
+				}
+				CB.SubmitLine(input)
 				CB.SetText("")
 				CB.SetBorderColor(tcell.Color27)
 				vermui.Unfocus()
 			}
 		case tcell.KeyEscape:
+			// on an empty input, Escape cancels the newest running command
+			if CB.GetText() == "" && CB.Kill(0) {
+				go events.SendCustomEvent("/status/message", "cancelled the running command")
+			}
 			CB.SetText("")
 			CB.SetBorderColor(tcell.Color27)
 			vermui.Unfocus()
 		case tcell.KeyTab:
+			CB.complete(1)
 		case tcell.KeyBacktab:
+			CB.complete(-1)
 		default:
 			go events.SendCustomEvent("/console/warn", fmt.Sprintf("cmdbox (fin-???-key): %v", key))
 
@@ -148,32 +230,83 @@ func (CB *CmdBoxWidget) Submit(command string, args []string) {
 		return
 	}
 
-	CB.Lock()
 	if len(args) == 0 {
-		CB.history = append(CB.history, command)
+		CB.addHistory(command)
 	} else {
-		CB.history = append(CB.history, command+" "+strings.Join(args, " "))
+		CB.addHistory(command + " " + Join(args))
+	}
+
+	if _, err := CB.run(context.Background(), command, args); err != nil {
+		vermui.Unfocus()
+		// render for the user
+		go events.SendCustomEvent("/user/error", err.Error())
+		// log to console
+		go events.SendCustomEvent("/console/warn", err.Error())
+	}
+}
+
+// run starts a command without recording it in the history, with a
+// context derived from ctx. The Execution is nil when the input
+// navigated instead.
+func (CB *CmdBoxWidget) run(ctx context.Context, command string, args []string) (*Execution, error) {
+	cmd, command, args, cmdContext, err := CB.resolve(command, args)
+	if err != nil {
+		return nil, err
+	}
+	if cmd == nil {
+		go events.SendCustomEvent("/router/dispatch", command)
+		return nil, nil
+	}
+	return CB.execute(ctx, cmd, command, args, cmdContext, nil, nil), nil
+}
+
+// resolve expands aliases, finds the command and parses its declared
+// arguments. The Command is nil if the input is a path to navigate to.
+func (CB *CmdBoxWidget) resolve(command string, args []string) (Command, string, []string, map[string]interface{}, error) {
+	command, args, err := CB.expandAlias(command, args)
+	if err != nil {
+		return nil, command, args, nil, err
+	}
+
+	if command == "" {
+		return nil, command, args, nil, errEmptyCommand
 	}
-	CB.Unlock()
 
 	command = strings.ToLower(command)
 	if command[:1] == "/" {
-		go events.SendCustomEvent("/router/dispatch", command)
-		return
+		return nil, command, args, nil, nil
 	}
 	CB.Lock()
 	cmd, ok := CB.commands[command]
 	CB.Unlock()
 	if !ok {
-		vermui.Unfocus()
-		// render for the user
-		go events.SendCustomEvent("/user/error", fmt.Sprintf("unknown command %q", command))
-		// log to console
-		go events.SendCustomEvent("/console/warn", fmt.Sprintf("unknown command %q", command))
-		return
+		return nil, command, args, nil, fmt.Errorf("unknown command %q", command)
+	}
+
+	context := map[string]interface{}{}
+	if ac, ok := cmd.(ArgsCommand); ok {
+		parsed, err := ac.CommandArgs().Parse(args)
+		if err != nil {
+			return nil, command, args, nil, fmt.Errorf("%s: %v\nusage: %s", command, err, cmd.CommandUsage())
+		}
+		context["args"] = parsed
 	}
 
-	go cmd.CommandCallback(args, nil)
+	return cmd, command, args, context, nil
+}
+
+// Paste inserts pasted text into the input, for vermui.Paste,
+// ending a history search first.
+func (CB *CmdBoxWidget) Paste(text string) {
+	if CB.search != nil {
+		CB.endSearch(true)
+	}
+	vermui.PasteInput(CB.InputField, text)
+}
+
+// CopyText returns the command being typed, for vermui.CopyFocused.
+func (CB *CmdBoxWidget) CopyText() string {
+	return CB.GetText()
 }
 
 // InputHandler returns the handler for this primitive.
@@ -183,6 +316,10 @@ func (CB *CmdBoxWidget) InputHandler() func(tcell.Event, func(tview.Primitive))
 		switch evt := event.(type) {
 		case *tcell.EventKey:
 
+			if CB.search != nil && CB.searchKey(evt) {
+				return
+			}
+
 			dist := 1
 
 			// Process key evt.
@@ -228,6 +365,10 @@ func (CB *CmdBoxWidget) InputHandler() func(tcell.Event, func(tview.Primitive))
 					CB.SetText(CB.history[CB.hIdx])
 				}
 
+			// Reverse incremental search through history
+			case tcell.KeyCtrlR:
+				CB.startSearch()
+
 			// Default is to pass through to InputField handler
 			default:
 				CB.hIdx = len(CB.history)
@@ -0,0 +1,94 @@
+package cmdbox
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/verdverm/tview"
+
+	"github.com/verdverm/vermui"
+	"github.com/verdverm/vermui/events"
+)
+
+// JobsView lists the jobs of a command box, newest last,
+// refreshing as they start, progress and finish.
+type JobsView struct {
+	*tview.TextView
+
+	CB *CmdBoxWidget
+
+	stop chan struct{}
+
+	// refresh runs on both the ticker and the event handler
+	mu sync.Mutex
+}
+
+func NewJobsView(cb *CmdBoxWidget) *JobsView {
+	textView := tview.NewTextView().
+		SetScrollable(true).
+		SetDynamicColors(true).
+		SetWrap(false).
+		SetChangedFunc(func() {
+			vermui.Draw()
+		})
+
+	textView.SetTitle(" jobs ").SetBorder(true)
+
+	return &JobsView{
+		TextView: textView,
+		CB:       cb,
+	}
+}
+
+func (J *JobsView) Mount(context map[string]interface{}) error {
+	vermui.AddWidgetHandler(J, "/cmdbox/job", func(e events.Event) {
+		J.refresh()
+	})
+
+	// keep the elapsed times of running jobs moving
+	J.stop = make(chan struct{})
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				J.refresh()
+			case <-stop:
+				return
+			}
+		}
+	}(J.stop)
+
+	J.refresh()
+	return nil
+}
+
+func (J *JobsView) Unmount() error {
+	vermui.RemoveWidgetHandler(J, "/cmdbox/job")
+	if J.stop != nil {
+		close(J.stop)
+		J.stop = nil
+	}
+	return nil
+}
+
+func (J *JobsView) refresh() {
+	J.mu.Lock()
+	defer J.mu.Unlock()
+
+	var b bytes.Buffer
+	for _, ex := range J.CB.Jobs() {
+		color := "white"
+		if !ex.Running() {
+			color = "gray"
+			if ex.Err() != nil {
+				color = "orange"
+			}
+		}
+		fmt.Fprintf(&b, "[%s]%s[white]\n", color, escapeTags(ex.String()))
+	}
+	J.SetText(b.String())
+}
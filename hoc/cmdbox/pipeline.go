@@ -0,0 +1,241 @@
+package cmdbox
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/verdverm/vermui/events"
+)
+
+// ConsoleOutput is the redirect name for the console,
+// where output goes when it is not redirected.
+const ConsoleOutput = "console"
+
+// errEmptyCommand is the error for a command which is an empty word,
+// like a pair of quotes or a variable with no value.
+var errEmptyCommand = errors.New("empty command")
+
+// ParseLine splits a line of input into the commands of a pipeline,
+// separated by '|', and the output named by a trailing "> name".
+func ParseLine(input string) (stages [][]string, redirect string, err error) {
+	return parseLine(input, nil)
+}
+
+// parseLine is ParseLine, expanding variables with expand, see lex.
+func parseLine(input string, expand func(string) string) (stages [][]string, redirect string, err error) {
+	words, isOp, err := lex(input, true, expand)
+	if err != nil {
+		return nil, "", err
+	}
+
+	stage := []string{}
+	for i := 0; i < len(words); i++ {
+		if !isOp[i] {
+			stage = append(stage, words[i])
+			continue
+		}
+
+		if len(stage) == 0 {
+			return nil, "", fmt.Errorf("missing command before %q", words[i])
+		}
+		if stage[0] == "" {
+			return nil, "", errEmptyCommand
+		}
+		stages = append(stages, stage)
+		stage = []string{}
+
+		if words[i] == ">" {
+			if i+1 >= len(words) || isOp[i+1] {
+				return nil, "", errors.New("missing output name after '>'")
+			}
+			if i+2 < len(words) {
+				return nil, "", errors.New("'>' has to be last")
+			}
+			return stages, words[i+1], nil
+		}
+	}
+
+	if len(stage) == 0 {
+		if len(stages) > 0 {
+			return nil, "", errors.New("missing command after '|'")
+		}
+		return nil, "", nil
+	}
+	if stage[0] == "" {
+		return nil, "", errEmptyCommand
+	}
+	return append(stages, stage), "", nil
+}
+
+// AddOutput registers a writer, like a tview.TextView,
+// as a target for "> name" redirects.
+func (CB *CmdBoxWidget) AddOutput(name string, w io.Writer) {
+	CB.Lock()
+	defer CB.Unlock()
+	CB.outputs[name] = w
+}
+
+func (CB *CmdBoxWidget) RemoveOutput(name string) {
+	CB.Lock()
+	defer CB.Unlock()
+	delete(CB.outputs, name)
+}
+
+// Outputs returns the names redirects can go to.
+func (CB *CmdBoxWidget) Outputs() []string {
+	CB.Lock()
+	defer CB.Unlock()
+	names := []string{ConsoleOutput}
+	for name := range CB.outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// SubmitLine runs a line of input like Enter does, which may
+// be a pipeline and may end in a redirect, see ParseLine.
+// A line starting with '!' is sent to a shell with "/shell/run".
+func (CB *CmdBoxWidget) SubmitLine(input string) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return
+	}
+	CB.addHistory(input)
+
+	if _, err := CB.startLine(context.Background(), input, nil); err != nil {
+		// render for the user
+		go events.SendCustomEvent("/user/error", err.Error())
+		// log to console
+		go events.SendCustomEvent("/console/warn", err.Error())
+	}
+}
+
+// startLine starts the commands of a line of input, returning the last
+// one of a pipeline, or nil if the input navigated or went to a shell.
+// The commands run with contexts derived from ctx. Variables in the
+// words are expanded with expand, if not nil, but a line for the shell
+// is sent as it is, the shell expands it.
+func (CB *CmdBoxWidget) startLine(ctx context.Context, input string, expand func(string) string) (*Execution, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil, nil
+	}
+	if input[0] == '!' {
+		if line := strings.TrimSpace(input[1:]); line != "" {
+			go events.SendCustomEvent("/shell/run", line)
+		}
+		return nil, nil
+	}
+
+	stages, redirect, err := parseLine(input, expand)
+	if err != nil || len(stages) == 0 {
+		return nil, err
+	}
+	if len(stages) == 1 && redirect == "" {
+		return CB.run(ctx, stages[0][0], stages[0][1:])
+	}
+
+	var out io.Writer
+	if redirect != "" && redirect != ConsoleOutput {
+		CB.Lock()
+		w, ok := CB.outputs[redirect]
+		CB.Unlock()
+		if !ok {
+			return nil, fmt.Errorf("no output named %q", redirect)
+		}
+		out = w
+	}
+
+	// check them all before starting any
+	type resolved struct {
+		cmd     Command
+		command string
+		args    []string
+		context map[string]interface{}
+	}
+	rs := make([]resolved, len(stages))
+	for i, stage := range stages {
+		cmd, command, args, context, err := CB.resolve(stage[0], stage[1:])
+		if err != nil {
+			return nil, err
+		}
+		if cmd == nil {
+			return nil, fmt.Errorf("can't pipe or redirect %s", command)
+		}
+		rs[i] = resolved{cmd, command, args, context}
+	}
+
+	var in io.Reader
+	var ex *Execution
+	for i, r := range rs {
+		stageOut := out
+		var pr *io.PipeReader
+		var pw *io.PipeWriter
+		if i < len(rs)-1 {
+			pr, pw = io.Pipe()
+			stageOut = pw
+		}
+
+		ex = CB.execute(ctx, r.cmd, r.command, r.args, r.context, in, stageOut)
+
+		// when a command is done, the next one gets EOF and
+		// the previous one an error if it is still writing
+		go func(ex *Execution, in io.Reader, pw *io.PipeWriter) {
+			<-ex.Done()
+			if pw != nil {
+				pw.Close()
+			}
+			if pr, ok := in.(*io.PipeReader); ok {
+				pr.Close()
+			}
+		}(ex, in, pw)
+
+		in = pr
+	}
+
+	return ex, nil
+}
+
+func (CB *CmdBoxWidget) echoCommand(args []string, context map[string]interface{}) {
+	if ex := ExecFromContext(context); ex != nil {
+		fmt.Fprintln(ex.Out, strings.Join(args, " "))
+	}
+}
+
+func (CB *CmdBoxWidget) grepCommand(args []string, context map[string]interface{}) {
+	ex := ExecFromContext(context)
+	if ex == nil {
+		return
+	}
+
+	invert := len(args) > 0 && args[0] == "-v"
+	if invert {
+		args = args[1:]
+	}
+	if len(args) != 1 {
+		go events.SendCustomEvent("/user/error", "usage: grep [-v] <regexp>")
+		return
+	}
+	re, err := regexp.Compile(args[0])
+	if err != nil {
+		go events.SendCustomEvent("/user/error", fmt.Sprintf("grep: %v", err))
+		return
+	}
+
+	scanner := bufio.NewScanner(ex.In)
+	for scanner.Scan() {
+		if re.MatchString(scanner.Text()) != invert {
+			if _, err := fmt.Fprintln(ex.Out, scanner.Text()); err != nil {
+				return
+			}
+		}
+	}
+}
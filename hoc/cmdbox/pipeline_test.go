@@ -0,0 +1,50 @@
+package cmdbox
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		input    string
+		stages   [][]string
+		redirect string
+		err      bool
+	}{
+		{"", nil, "", false},
+		{"jobs", [][]string{{"jobs"}}, "", false},
+		{"history | grep open", [][]string{{"history"}, {"grep", "open"}}, "", false},
+		{"history|grep open>log", [][]string{{"history"}, {"grep", "open"}}, "log", false},
+		{`echo 'a | b' "> c"`, [][]string{{"echo", "a | b", "> c"}}, "", false},
+		{`echo a\|b`, [][]string{{"echo", "a|b"}}, "", false},
+		{"echo hi > console", [][]string{{"echo", "hi"}}, "console", false},
+		{"| grep x", nil, "", true},
+		{"history |", nil, "", true},
+		{"history | | grep x", nil, "", true},
+		{"echo hi >", nil, "", true},
+		{"echo hi > log | grep x", nil, "", true},
+		{"echo hi > log extra", nil, "", true},
+		{`""`, nil, "", true},
+		{"'' x", nil, "", true},
+		{`echo | ""`, nil, "", true},
+		{`"" | grep x`, nil, "", true},
+	}
+
+	for _, test := range tests {
+		stages, redirect, err := ParseLine(test.input)
+		if test.err {
+			if err == nil {
+				t.Errorf("ParseLine(%q): expected an error, got %q > %q", test.input, stages, redirect)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseLine(%q): unexpected error: %v", test.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(stages, test.stages) || redirect != test.redirect {
+			t.Errorf("ParseLine(%q): expected %q > %q, got %q > %q", test.input, test.stages, test.redirect, stages, redirect)
+		}
+	}
+}
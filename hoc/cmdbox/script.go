@@ -0,0 +1,164 @@
+package cmdbox
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/verdverm/vermui/events"
+)
+
+// maxSourceDepth limits scripts sourcing scripts.
+const maxSourceDepth = 8
+
+// sourceDepthKey is the context key of how deep scripts are nested.
+type sourceDepthKey struct{}
+
+// SetRCFile sets a script to run when the command box is first mounted.
+// A missing file is fine.
+func (CB *CmdBoxWidget) SetRCFile(path string) {
+	CB.Lock()
+	defer CB.Unlock()
+	CB.rcFile = path
+}
+
+func (CB *CmdBoxWidget) runRC(path string) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return
+	}
+	if err := CB.Source(context.Background(), path); err != nil {
+		go events.SendCustomEvent("/user/error", err.Error())
+		go events.SendCustomEvent("/console/error", err)
+	}
+}
+
+// Source runs the commands in a script file, one per line, waiting for
+// each to finish before the next. Lines starting with '#' are comments,
+// "set name = value" sets a variable, and $name or ${name} expand to a
+// variable, or to the environment if there is no such variable, but
+// not inside single quotes. The first failing line stops the script,
+// as does cancelling ctx.
+func (CB *CmdBoxWidget) Source(ctx context.Context, path string) error {
+	depth, _ := ctx.Value(sourceDepthKey{}).(int)
+	if depth >= maxSourceDepth {
+		return fmt.Errorf("source %s: scripts nested too deep", path)
+	}
+	// the commands of the script run with it, a nested source sees it
+	ctx = context.WithValue(ctx, sourceDepthKey{}, depth+1)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "in Source")
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		if err := CB.runLine(ctx, scanner.Text()); err != nil {
+			return fmt.Errorf("%s:%d: %v", path, lineno, err)
+		}
+	}
+	return errors.Wrap(scanner.Err(), "in Source")
+}
+
+func (CB *CmdBoxWidget) runLine(ctx context.Context, line string) error {
+	line = strings.TrimSpace(line)
+	if line == "" || line[0] == '#' {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ex, err := CB.startLine(ctx, line, CB.Expand)
+	if err != nil || ex == nil {
+		return err
+	}
+
+	select {
+	case <-ex.Done():
+		return ex.Err()
+	case <-ctx.Done():
+		ex.Cancel()
+		return ctx.Err()
+	}
+}
+
+// SetVar sets a variable for scripts.
+func (CB *CmdBoxWidget) SetVar(name, value string) {
+	CB.Lock()
+	defer CB.Unlock()
+	CB.vars[name] = value
+}
+
+// Expand replaces $name and ${name} in text with the variable, or the
+// environment variable, of that name. "$$" is a literal '$'. Scripts
+// expand the words of a line after splitting it, so a value with
+// spaces stays a single word.
+func (CB *CmdBoxWidget) Expand(text string) string {
+	text = strings.Replace(text, "$$", "\x00", -1)
+	text = os.Expand(text, func(name string) string {
+		CB.Lock()
+		value, ok := CB.vars[name]
+		CB.Unlock()
+		if ok {
+			return value
+		}
+		return os.Getenv(name)
+	})
+	return strings.Replace(text, "\x00", "$", -1)
+}
+
+func (CB *CmdBoxWidget) sourceCommand(args []string, cmdContext map[string]interface{}) {
+	if len(args) != 1 {
+		go events.SendCustomEvent("/user/error", "usage: source <file>")
+		return
+	}
+
+	ctx := context.Background()
+	if ex := ExecFromContext(cmdContext); ex != nil {
+		ctx = ex.Context()
+	}
+	if err := CB.Source(ctx, args[0]); err != nil {
+		go events.SendCustomEvent("/user/error", err.Error())
+		go events.SendCustomEvent("/console/warn", err.Error())
+	}
+}
+
+func (CB *CmdBoxWidget) setCommand(args []string, context map[string]interface{}) {
+	// list them all
+	if len(args) == 0 {
+		CB.Lock()
+		lines := []string{}
+		for name, value := range CB.vars {
+			lines = append(lines, fmt.Sprintf("%s = %s", name, value))
+		}
+		CB.Unlock()
+		sort.Strings(lines)
+		CB.Popup("variables", strings.Join(lines, "\n"))
+		return
+	}
+
+	def := Join(args)
+	i := strings.Index(def, "=")
+	if i < 0 {
+		go events.SendCustomEvent("/user/error", "usage: set name = value")
+		return
+	}
+	name := strings.TrimSpace(def[:i])
+	if name == "" || strings.ContainsAny(name, " \t${}") {
+		go events.SendCustomEvent("/user/error", fmt.Sprintf("set: bad variable name %q", name))
+		return
+	}
+	// unquote the value, so "set x = 'a b'" stores a b
+	value := strings.TrimSpace(def[i+1:])
+	if flds, err := Tokenize(value); err == nil {
+		value = strings.Join(flds, " ")
+	}
+	CB.SetVar(name, value)
+}
@@ -0,0 +1,202 @@
+package cmdbox
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell"
+	"github.com/mattn/go-runewidth"
+)
+
+type inputState int
+
+const (
+	inputValid      inputState = iota
+	inputIncomplete            // an argument is missing or bad, or a quote is open
+	inputInvalid               // an unknown command or output
+)
+
+var (
+	// command name colors
+	commandColor = tcell.ColorGreen
+	pathColor    = tcell.ColorAqua
+	unknownColor = tcell.ColorRed
+
+	// border colors for the input states
+	incompleteColor = tcell.ColorYellow
+	invalidColor    = tcell.ColorRed
+
+	focusedBorder = tcell.Color69
+)
+
+// validate colors the command name by whether it is known, and the
+// border by whether the input would run, with a hint on why not, or
+// the usage, in the title.
+func (CB *CmdBoxWidget) validate(text string) {
+	if CB.search != nil {
+		return
+	}
+
+	state, hint := CB.check(text)
+	switch state {
+	case inputValid:
+		CB.SetBorderColor(focusedBorder)
+	case inputIncomplete:
+		CB.SetBorderColor(incompleteColor)
+	case inputInvalid:
+		CB.SetBorderColor(invalidColor)
+	}
+
+	if hint != "" {
+		hint = " " + escapeTags(hint) + " "
+	}
+	CB.SetTitle(hint)
+}
+
+// Draw draws the input field, then colors the command name in it.
+func (CB *CmdBoxWidget) Draw(screen tcell.Screen) {
+	CB.InputField.Draw(screen)
+	if CB.search != nil {
+		return
+	}
+
+	text := CB.GetText()
+	start, end := commandSpan(text)
+	if start == end {
+		return
+	}
+	color, ok := CB.nameColor(text[start:end])
+	if !ok {
+		return
+	}
+
+	x, y, width, height := CB.GetInnerRect()
+	if height <= 0 {
+		return
+	}
+	label := runewidth.StringWidth(CB.GetLabel())
+	x, width = x+label, width-label
+	// the field scrolls when the text and the cursor do not fit
+	if runewidth.StringWidth(text) >= width {
+		return
+	}
+
+	x += runewidth.StringWidth(text[:start])
+	for _, r := range text[start:end] {
+		_, comb, style, _ := screen.GetContent(x, y)
+		screen.SetContent(x, y, r, comb, style.Foreground(color))
+		x += runewidth.RuneWidth(r)
+	}
+}
+
+// commandSpan returns where the first word of text is, quotes and all.
+func commandSpan(text string) (start, end int) {
+	start = len(text) - len(strings.TrimLeft(text, " \t"))
+	if start < len(text) && text[start] == '!' {
+		return start, start
+	}
+	var quote rune
+	escaped := false
+	for i, r := range text[start:] {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\\':
+			escaped = true
+		case r == '\'' || r == '"':
+			quote = r
+		case r == ' ' || r == '\t' || r == '|' || r == '>':
+			return start, start + i
+		}
+	}
+	return start, len(text)
+}
+
+// nameColor is the color of a command name, as typed, by whether it is
+// a path, a command or an alias of one, or unknown.
+func (CB *CmdBoxWidget) nameColor(word string) (tcell.Color, bool) {
+	words, err := Tokenize(word)
+	if err != nil || len(words) != 1 {
+		return 0, false
+	}
+	command, _, err := CB.expandAlias(words[0], nil)
+	if err != nil || command == "" {
+		return unknownColor, true
+	}
+	if command[:1] == "/" {
+		return pathColor, true
+	}
+
+	CB.Lock()
+	_, ok := CB.commands[strings.ToLower(command)]
+	CB.Unlock()
+	if !ok {
+		return unknownColor, true
+	}
+	return commandColor, true
+}
+
+// check reports whether input would run, with a hint.
+func (CB *CmdBoxWidget) check(input string) (inputState, string) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return inputValid, ""
+	}
+	if input[0] == '!' {
+		return inputValid, "run in a shell"
+	}
+
+	stages, redirect, err := ParseLine(input)
+	if err != nil {
+		return inputIncomplete, err.Error()
+	}
+
+	if redirect != "" && redirect != ConsoleOutput {
+		CB.Lock()
+		_, ok := CB.outputs[redirect]
+		CB.Unlock()
+		if !ok {
+			return inputInvalid, fmt.Sprintf("no output named %q", redirect)
+		}
+	}
+
+	hint := ""
+	for _, stage := range stages {
+		command, args, err := CB.expandAlias(stage[0], stage[1:])
+		if err != nil {
+			return inputInvalid, err.Error()
+		}
+
+		if command == "" {
+			return inputInvalid, errEmptyCommand.Error()
+		}
+
+		command = strings.ToLower(command)
+		if command[:1] == "/" {
+			if len(stages) > 1 || redirect != "" {
+				return inputInvalid, fmt.Sprintf("can't pipe or redirect %s", command)
+			}
+			return inputValid, "navigate to " + command
+		}
+
+		CB.Lock()
+		cmd, ok := CB.commands[command]
+		CB.Unlock()
+		if !ok {
+			return inputInvalid, fmt.Sprintf("unknown command %q", command)
+		}
+
+		if ac, ok := cmd.(ArgsCommand); ok {
+			if _, err := ac.CommandArgs().Parse(args); err != nil {
+				return inputIncomplete, fmt.Sprintf("%v, usage: %s", err, cmd.CommandUsage())
+			}
+		}
+		hint = cmd.CommandUsage()
+	}
+
+	return inputValid, hint
+}
@@ -71,6 +71,12 @@ func (C *DevConsoleWidget) Mount(context map[string]interface{}) error {
 	return nil
 }
 
+// CopyText is the whole console log as plain text, including
+// the lines scrolled out of view.
+func (C *DevConsoleWidget) CopyText() string {
+	return C.GetText(true)
+}
+
 func (C *DevConsoleWidget) Unmount() error {
 	vermui.RemoveWidgetHandler(C, "/console")
 	return nil
@@ -51,6 +51,13 @@ func (C *ErrConsoleWidget) Mount(context map[string]interface{}) error {
 
 	return nil
 }
+
+// CopyText is every error shown so far, one per line, without the
+// color tags, to paste into a bug report.
+func (C *ErrConsoleWidget) CopyText() string {
+	return C.GetText(true)
+}
+
 func (C *ErrConsoleWidget) Unmount() error {
 	vermui.RemoveWidgetHandler(C, "/user/error")
 	vermui.RemoveWidgetHandler(C, "/sys/err")
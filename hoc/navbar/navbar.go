@@ -0,0 +1,249 @@
+// Package navbar is a navigation bar for a router, a breadcrumb of the
+// current path followed by tabs for the named routes.
+package navbar
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/gdamore/tcell"
+	"github.com/mattn/go-runewidth"
+	"github.com/verdverm/tview"
+
+	"github.com/verdverm/vermui"
+	"github.com/verdverm/vermui/events"
+	"github.com/verdverm/vermui/hoc/router"
+)
+
+const (
+	crumbSep = " > "
+	tabsSep  = "  |  "
+)
+
+// item is a segment of the breadcrumb or a tab,
+// x and w are where it was last drawn.
+type item struct {
+	label  string
+	path   string
+	tab    bool
+	active bool
+
+	x, w int
+}
+
+type NavBar struct {
+	*tview.Box
+
+	// Key focuses the bar, Left and Right then select
+	// and Enter navigates. Set it before Mount.
+	Key string
+
+	router *router.Router
+
+	mu    sync.Mutex
+	items []item
+	sel   int
+}
+
+// New creates a navigation bar for r, mount it to follow navigation.
+func New(r *router.Router) *NavBar {
+	N := &NavBar{
+		Box:    tview.NewBox(),
+		Key:    "C-b",
+		router: r,
+	}
+	N.update(nil)
+	return N
+}
+
+func (N *NavBar) Mount(context map[string]interface{}) error {
+	vermui.AddWidgetHandler(N, "/router/changed", func(e events.Event) {
+		if entry, ok := e.Data.(*events.EventCustom).Data().(*router.Entry); ok {
+			N.update(entry)
+			vermui.Draw()
+		}
+	})
+
+	vermui.AddWidgetHandler(N, "/sys/mouse/click/<left>", func(e events.Event) {
+		if m, ok := e.Data.(events.EventMouse); ok {
+			N.click(m.X)
+		}
+	})
+
+	vermui.AddWidgetHandler(N, "/sys/key/"+N.Key, func(e events.Event) {
+		vermui.SetFocus(N)
+	})
+
+	return nil
+}
+
+func (N *NavBar) Unmount() error {
+	vermui.RemoveWidgetHandler(N, "/router/changed")
+	vermui.RemoveWidgetHandler(N, "/sys/mouse/click/<left>")
+	vermui.RemoveWidgetHandler(N, "/sys/key/"+N.Key)
+	return nil
+}
+
+// update rebuilds the breadcrumb for entry, and the tabs.
+func (N *NavBar) update(entry *router.Entry) {
+	items := []item{{label: "/", path: "/"}}
+
+	path, tpl := "/", ""
+	if entry != nil {
+		path, tpl = entry.Path, entry.Template
+	}
+	prefix := ""
+	for _, seg := range strings.Split(strings.Trim(path, "/"), "/") {
+		if seg == "" {
+			continue
+		}
+		prefix += "/" + seg
+		items = append(items, item{label: seg, path: prefix})
+	}
+	items[len(items)-1].active = true
+
+	if N.router != nil {
+		for _, ri := range N.router.Routes() {
+			// tabs for the named routes which need no vars
+			if ri.Name == "" || strings.Contains(ri.Path, "{") {
+				continue
+			}
+			items = append(items, item{
+				label:  ri.Name,
+				path:   ri.Path,
+				tab:    true,
+				active: ri.Path == tpl,
+			})
+		}
+	}
+
+	N.mu.Lock()
+	N.items = items
+	N.sel = 0
+	for i, it := range items {
+		if it.active && !it.tab {
+			N.sel = i
+		}
+	}
+	N.mu.Unlock()
+}
+
+func (N *NavBar) Draw(screen tcell.Screen) {
+	N.Box.Draw(screen)
+	x, y, width, height := N.GetInnerRect()
+	if height <= 0 {
+		return
+	}
+	right := x + width
+
+	N.mu.Lock()
+	defer N.mu.Unlock()
+
+	focused := N.HasFocus()
+	put := func(text string, style tcell.Style) int {
+		start := x
+		for _, r := range text {
+			w := runewidth.RuneWidth(r)
+			if x+w > right {
+				break
+			}
+			screen.SetContent(x, y, r, nil, style)
+			x += w
+		}
+		return x - start
+	}
+
+	plain := tcell.StyleDefault.Background(tview.Styles.PrimitiveBackgroundColor)
+	sepStyle := plain.Foreground(tcell.ColorGray)
+	for i := range N.items {
+		it := &N.items[i]
+		if i > 0 {
+			if it.tab && !N.items[i-1].tab {
+				put(tabsSep, sepStyle)
+			} else if !it.tab {
+				put(crumbSep, sepStyle)
+			} else {
+				put(" ", plain)
+			}
+		}
+
+		style := plain.Foreground(tcell.ColorSilver)
+		label := it.label
+		if it.tab {
+			label = " " + label + " "
+			if it.active {
+				style = style.Background(tcell.Color27).Foreground(tcell.ColorWhite)
+			}
+		} else if it.active {
+			style = style.Foreground(tcell.ColorWhite).Bold(true)
+		}
+		if focused && i == N.sel {
+			style = style.Reverse(true)
+		}
+
+		it.x = x
+		it.w = put(label, style)
+	}
+}
+
+// click navigates to the item drawn at column x.
+func (N *NavBar) click(x int) {
+	N.mu.Lock()
+	path := ""
+	for i, it := range N.items {
+		if x >= it.x && x < it.x+it.w {
+			path = it.path
+			N.sel = i
+		}
+	}
+	N.mu.Unlock()
+
+	if path != "" {
+		go events.SendCustomEvent("/router/dispatch", path)
+	}
+}
+
+func (N *NavBar) move(dist int) {
+	N.mu.Lock()
+	defer N.mu.Unlock()
+	N.sel += dist
+	if N.sel < 0 {
+		N.sel = 0
+	}
+	if N.sel >= len(N.items) {
+		N.sel = len(N.items) - 1
+	}
+}
+
+// InputHandler returns the handler for this primitive.
+func (N *NavBar) InputHandler() func(tcell.Event, func(tview.Primitive)) {
+	return N.WrapInputHandler(func(event tcell.Event, setFocus func(p tview.Primitive)) {
+		evt, ok := event.(*tcell.EventKey)
+		if !ok {
+			return
+		}
+
+		switch evt.Key() {
+		case tcell.KeyLeft, tcell.KeyBacktab:
+			N.move(-1)
+		case tcell.KeyRight, tcell.KeyTab:
+			N.move(1)
+		case tcell.KeyHome:
+			N.mu.Lock()
+			N.sel = 0
+			N.mu.Unlock()
+		case tcell.KeyEnd:
+			N.mu.Lock()
+			N.sel = len(N.items) - 1
+			N.mu.Unlock()
+		case tcell.KeyEnter:
+			N.mu.Lock()
+			path := N.items[N.sel].path
+			N.mu.Unlock()
+			go events.SendCustomEvent("/router/dispatch", path)
+			vermui.Unfocus()
+		case tcell.KeyEscape:
+			vermui.Unfocus()
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package palette
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Score fuzzy matches pattern against text, case-insensitively.
+// Every rune of pattern has to appear in text in order. Runs of
+// consecutive runes, word starts and an early first match score
+// higher. ok is false if there is no match.
+func Score(pattern, text string) (score int, ok bool) {
+	if pattern == "" {
+		return 0, true
+	}
+
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(text)
+	lt := []rune(strings.ToLower(text))
+
+	pi := 0
+	run := 0
+	first := -1
+	for ti := 0; ti < len(lt) && pi < len(p); ti++ {
+		if lt[ti] != p[pi] {
+			run = 0
+			continue
+		}
+		if first < 0 {
+			first = ti
+		}
+
+		score += 1
+		if run > 0 {
+			score += 4 * run
+		}
+		if ti == 0 || isBoundary(t[ti-1], t[ti]) {
+			score += 6
+		}
+		run++
+		pi++
+	}
+
+	if pi < len(p) {
+		return 0, false
+	}
+
+	// prefer matches near the start and shorter texts
+	if first < 8 {
+		score += 8 - first
+	}
+	score -= len(t) / 8
+
+	return score, true
+}
+
+// isBoundary reports whether cur starts a word.
+func isBoundary(prev, cur rune) bool {
+	if !unicode.IsLetter(prev) && !unicode.IsDigit(prev) {
+		return true
+	}
+	return unicode.IsLower(prev) && unicode.IsUpper(cur)
+}
@@ -0,0 +1,38 @@
+package palette
+
+import (
+	"testing"
+)
+
+func TestScore(t *testing.T) {
+	misses := [][2]string{
+		{"xyz", "history"},
+		{"yh", "history"},
+		{"routes", "route"},
+	}
+	for _, m := range misses {
+		if _, ok := Score(m[0], m[1]); ok {
+			t.Errorf("Score(%q, %q): expected no match", m[0], m[1])
+		}
+	}
+
+	// the first text should rank above the second
+	ranks := [][3]string{
+		{"his", "history", "this is"},
+		{"ur", "/users/{id}", "/jobs/current"},
+		{"help", "help", "helpful-tips"},
+		{"gs", "/git/status", "settings"},
+		{"TC", "TabComplete", "attic"},
+	}
+	for _, r := range ranks {
+		a, okA := Score(r[0], r[1])
+		b, okB := Score(r[0], r[2])
+		if !okA || !okB {
+			t.Errorf("Score(%q): expected both %q and %q to match", r[0], r[1], r[2])
+			continue
+		}
+		if a <= b {
+			t.Errorf("Score(%q): expected %q (%d) above %q (%d)", r[0], r[1], a, r[2], b)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+// Package palette is a command palette, a filterable overlay listing
+// the commands of a command box and the named routes of a router.
+package palette
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell"
+	"github.com/verdverm/tview"
+
+	"github.com/verdverm/vermui"
+	"github.com/verdverm/vermui/events"
+	"github.com/verdverm/vermui/hoc/cmdbox"
+	"github.com/verdverm/vermui/hoc/router"
+)
+
+const (
+	overlayName = "palette"
+	maxRecent   = 32
+)
+
+// Item is an entry in the palette.
+type Item struct {
+	Label  string // shown and matched
+	Detail string // usage or path template
+	Input  string // submitted to the command box
+
+	// The input is incomplete (required arguments, route variables),
+	// so it is put into the command box instead of submitted.
+	Prefill bool
+}
+
+type Palette struct {
+	*tview.Flex
+
+	// Key opens the palette, set before Mount.
+	Key string
+
+	input *paletteInput
+	list  *tview.List
+
+	cmdbox *cmdbox.CmdBoxWidget
+	router *router.Router
+
+	items  []Item
+	shown  []Item
+	sel    int
+	recent []string // inputs, most recent first
+}
+
+// paletteInput is the filter field, it moves the
+// selection and runs it, everything else is typing.
+type paletteInput struct {
+	*tview.InputField
+
+	P *Palette
+}
+
+// New creates a palette for the commands of cb and the named routes
+// of r, either may be nil. Without cb, routes with variables are left
+// out, since they are completed in the command box. Mount it to bind
+// the key.
+func New(cb *cmdbox.CmdBoxWidget, r *router.Router) *Palette {
+	P := &Palette{
+		Flex:   tview.NewFlex().SetDirection(tview.FlexRow),
+		Key:    "C-p",
+		list:   tview.NewList().ShowSecondaryText(true),
+		cmdbox: cb,
+		router: r,
+	}
+
+	P.input = &paletteInput{
+		InputField: tview.NewInputField().
+			SetLabel("> ").
+			SetFieldBackgroundColor(tview.Styles.PrimitiveBackgroundColor),
+		P: P,
+	}
+	P.input.SetChangedFunc(func(text string) {
+		P.filter(text)
+	})
+
+	P.Flex.
+		AddItem(P.input, 1, 0, true).
+		AddItem(P.list, 0, 1, false)
+	P.SetTitle(" commands ").SetBorder(true)
+
+	return P
+}
+
+func (P *Palette) Mount(context map[string]interface{}) error {
+	vermui.AddWidgetHandler(P, "/sys/key/"+P.Key, func(e events.Event) {
+		P.Open()
+	})
+	return nil
+}
+
+func (P *Palette) Unmount() error {
+	vermui.RemoveWidgetHandler(P, "/sys/key/"+P.Key)
+	return nil
+}
+
+// Open shows the palette with a fresh list and focuses it.
+func (P *Palette) Open() {
+	if vermui.HasOverlay(overlayName) {
+		return
+	}
+	P.items = P.collect()
+	P.input.SetText("")
+	P.filter("")
+
+	vermui.ShowOverlay(overlayName, P, vermui.Centered(72, 20))
+	vermui.SetFocus(P.input)
+}
+
+// Close hides the palette.
+func (P *Palette) Close() {
+	vermui.HideOverlay(overlayName)
+	vermui.Unfocus()
+}
+
+// collect gathers the commands and named routes.
+func (P *Palette) collect() []Item {
+	items := []Item{}
+
+	if P.cmdbox != nil {
+		for _, cmd := range P.cmdbox.Commands() {
+			item := Item{
+				Label:  cmd.CommandName(),
+				Detail: cmd.CommandUsage(),
+				Input:  cmd.CommandName(),
+			}
+			if ac, ok := cmd.(cmdbox.ArgsCommand); ok {
+				for _, a := range ac.CommandArgs().Args {
+					if a.Required {
+						item.Prefill = true
+						item.Input += " "
+						break
+					}
+				}
+			}
+			items = append(items, item)
+		}
+	}
+
+	if P.router != nil {
+		for _, ri := range P.router.Routes() {
+			if ri.Name == "" {
+				continue
+			}
+			item := Item{
+				Label:  ri.Name,
+				Detail: ri.Path,
+				Input:  ri.Path,
+			}
+			if i := strings.Index(ri.Path, "{"); i >= 0 {
+				// the vars are filled in in the command box
+				if P.cmdbox == nil {
+					continue
+				}
+				item.Input = ri.Path[:i]
+				item.Prefill = true
+			}
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// filter ranks the items by fuzzy score plus a bonus for recent use.
+func (P *Palette) filter(query string) {
+	type ranked struct {
+		item  Item
+		score int
+	}
+
+	rs := []ranked{}
+	for _, item := range P.items {
+		score, ok := Score(query, item.Label)
+		if !ok {
+			// the detail matches too, but counts less
+			if score, ok = Score(query, item.Detail); !ok {
+				continue
+			}
+			score /= 2
+		}
+		for i, input := range P.recent {
+			if input == item.Input {
+				score += 2 * (maxRecent - i)
+				break
+			}
+		}
+		rs = append(rs, ranked{item, score})
+	}
+	sort.SliceStable(rs, func(i, j int) bool {
+		if rs[i].score != rs[j].score {
+			return rs[i].score > rs[j].score
+		}
+		return rs[i].item.Label < rs[j].item.Label
+	})
+
+	P.shown = P.shown[:0]
+	P.list.Clear()
+	for _, r := range rs {
+		P.shown = append(P.shown, r.item)
+		P.list.AddItem(escapeTags(r.item.Label), "  [gray]"+escapeTags(r.item.Detail), 0, nil)
+	}
+	P.sel = 0
+	P.list.SetCurrentItem(0)
+	vermui.Draw()
+}
+
+func (P *Palette) move(dist int) {
+	if len(P.shown) == 0 {
+		return
+	}
+	P.sel += dist
+	if P.sel < 0 {
+		P.sel = 0
+	}
+	if P.sel >= len(P.shown) {
+		P.sel = len(P.shown) - 1
+	}
+	P.list.SetCurrentItem(P.sel)
+}
+
+// run closes the palette and submits the selected item
+// through the command box, or dispatches it if there is none.
+func (P *Palette) run() {
+	if P.sel >= len(P.shown) {
+		return
+	}
+	item := P.shown[P.sel]
+	P.Close()
+
+	// remember it, most recent first
+	recent := []string{item.Input}
+	for _, input := range P.recent {
+		if input != item.Input && len(recent) < maxRecent {
+			recent = append(recent, input)
+		}
+	}
+	P.recent = recent
+
+	// without a command box there are only routes, complete ones
+	if P.cmdbox == nil {
+		go events.SendCustomEvent("/router/dispatch", item.Input)
+		return
+	}
+	if item.Prefill {
+		P.cmdbox.Prefill(item.Input)
+		return
+	}
+	flds, err := cmdbox.Tokenize(item.Input)
+	if err != nil || len(flds) == 0 {
+		return
+	}
+	P.cmdbox.Submit(flds[0], flds[1:])
+}
+
+// InputHandler returns the handler for this primitive.
+func (PI *paletteInput) InputHandler() func(tcell.Event, func(tview.Primitive)) {
+	return PI.WrapInputHandler(func(event tcell.Event, setFocus func(p tview.Primitive)) {
+		handle := PI.InputField.InputHandler()
+		evt, ok := event.(*tcell.EventKey)
+		if !ok {
+			handle(event, setFocus)
+			return
+		}
+
+		switch evt.Key() {
+		case tcell.KeyUp, tcell.KeyCtrlP:
+			PI.P.move(-1)
+		case tcell.KeyDown, tcell.KeyCtrlN:
+			PI.P.move(1)
+		case tcell.KeyPgUp:
+			PI.P.move(-5)
+		case tcell.KeyPgDn:
+			PI.P.move(5)
+		case tcell.KeyEnter:
+			PI.P.run()
+		case tcell.KeyEscape:
+			PI.P.Close()
+		default:
+			handle(event, setFocus)
+		}
+	})
+}
+
+// tagPattern matches what tview would read as a color or region tag.
+var tagPattern = regexp.MustCompile(`(\[[a-zA-Z0-9_,;: \-\."#]+\[*)\]`)
+
+// escapeTags keeps text, like "[command]" in a usage, from being read
+// as color tags.
+func escapeTags(s string) string {
+	return tagPattern.ReplaceAllString(s, "$1[]")
+}
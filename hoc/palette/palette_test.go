@@ -0,0 +1,21 @@
+package palette
+
+import (
+	"testing"
+)
+
+func TestEscapeTags(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"help [command]", "help [command[]"},
+		{"history [count]", "history [count[]"},
+		{"/users/{id}", "/users/{id}"},
+		{"[red]x[-]", "[red[]x[-[]"},
+	}
+	for _, test := range tests {
+		if got := escapeTags(test.in); got != test.want {
+			t.Errorf("escapeTags(%q): expected %q, got %q", test.in, test.want, got)
+		}
+	}
+}
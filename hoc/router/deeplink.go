@@ -0,0 +1,44 @@
+package router
+
+import (
+	"os"
+	"strings"
+
+	"github.com/verdverm/vermui/events"
+)
+
+// RouteEnv is the environment variable with a route to start at.
+const RouteEnv = "VERMUI_ROUTE"
+
+// DeepLink finds the route to start at, "/jobs/123?tab=logs" say, in the
+// command line arguments, os.Args[1:], or else the environment. It is
+// the value of a "--route=path" or "--route path" argument, or else
+// $VERMUI_ROUTE, or "" if there is none. Other arguments, even ones
+// starting with '/', are left to the app.
+func DeepLink(args []string) string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case strings.HasPrefix(arg, "--route="):
+			return strings.TrimPrefix(arg, "--route=")
+		case arg == "--route" && i+1 < len(args):
+			return args[i+1]
+		}
+	}
+	return os.Getenv(RouteEnv)
+}
+
+// DispatchDeepLink navigates to the DeepLink in args or the environment,
+// with "deeplink" as the activation, once the app is running. It returns
+// the route, or "" if there is none and nothing happens.
+func (R *Router) DispatchDeepLink(args []string) string {
+	path := DeepLink(args)
+	if path == "" {
+		return ""
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	go events.SendCustomEvent("/router/deeplink", path)
+	return path
+}
@@ -0,0 +1,29 @@
+package router
+
+import (
+	"testing"
+)
+
+func TestDeepLink(t *testing.T) {
+	tests := []struct {
+		args []string
+		env  string
+		want string
+	}{
+		{nil, "", ""},
+		{nil, "/env", "/env"},
+		{[]string{"--route=/jobs/123?tab=logs"}, "", "/jobs/123?tab=logs"},
+		{[]string{"-v", "--route", "/jobs"}, "/env", "/jobs"},
+		{[]string{"--route"}, "/env", "/env"},
+		{[]string{"/tmp/x.yaml"}, "", ""},
+		{[]string{"/tmp/x.yaml"}, "/env", "/env"},
+		{[]string{"/tmp/x.yaml", "--route=/jobs"}, "", "/jobs"},
+	}
+
+	for i, tt := range tests {
+		t.Setenv(RouteEnv, tt.env)
+		if got := DeepLink(tt.args); got != tt.want {
+			t.Errorf("%d: DeepLink(%q) with %s=%q = %q, want %q", i, tt.args, RouteEnv, tt.env, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package router
+
+import (
+	"container/list"
+	"net/url"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/verdverm/tview"
+
+	"github.com/verdverm/vermui/mux"
+)
+
+// DefaultPageCacheSize is how many pages a factory
+// route keeps when AddRouteFactory is given 0.
+const DefaultPageCacheSize = 8
+
+// Factory builds the page for a route. It is called on the first visit
+// for each distinct set of route vars, "/repo/{name}" gets a page per name.
+type Factory func(route *Entry) (tview.Primitive, error)
+
+// pageCache holds the pages a Factory built, most recently used first.
+type pageCache struct {
+	mu    sync.Mutex
+	max   int
+	order *list.List // of *cachedPage
+	pages map[string]*list.Element
+}
+
+type cachedPage struct {
+	key  string
+	page tview.Primitive
+}
+
+// AddRouteFactory registers a route whose pages are built when first
+// visited, instead of up front. At most max pages are kept, 0 means
+// DefaultPageCacheSize, when there are more the least recently visited
+// one is unmounted and removed.
+func (R *Router) AddRouteFactory(path string, factory Factory, max int) error {
+	if max <= 0 {
+		max = DefaultPageCacheSize
+	}
+	cache := &pageCache{
+		max:   max,
+		order: list.New(),
+		pages: make(map[string]*list.Element),
+	}
+
+	// The router only serves a route once the guards let the navigation
+	// through, so pages are not built or evicted for vetoed ones.
+	handler := func(req *mux.Request) (tview.Primitive, *mux.Request, error) {
+		key := path + "?" + url.Values(varsValues(mux.Vars(req))).Encode()
+
+		// building under the lock, so a page is built once
+		// when it is visited from more than one place at a time
+		cache.mu.Lock()
+		if elem, ok := cache.pages[key]; ok {
+			cache.order.MoveToFront(elem)
+			cache.mu.Unlock()
+			return elem.Value.(*cachedPage).page, req, nil
+		}
+
+		page, err := factory(newEntry(req, req.Context))
+		if err != nil {
+			cache.mu.Unlock()
+			return nil, req, errors.Wrapf(err, "building the page for %q", req.Path)
+		}
+		if page == nil {
+			cache.mu.Unlock()
+			return nil, req, errors.Errorf("no page built for %q", req.Path)
+		}
+		R.addPage(page)
+
+		cache.pages[key] = cache.order.PushFront(&cachedPage{key, page})
+		evicted := cache.evict(R.shown())
+		cache.mu.Unlock()
+
+		for _, old := range evicted {
+			old.Unmount()
+			R.removePage(old)
+		}
+		return page, req, nil
+	}
+
+	route := R.iRouter.Handle(path, mux.NewDefaultHandler(handler))
+	R.setKind(route, "router.Factory")
+	return errors.Wrap(route.GetError(), "in AddRouteFactory")
+}
+
+// evict drops the least recently used pages past the limit,
+// skipping the one being shown and the one just visited.
+func (C *pageCache) evict(shown tview.Primitive) []tview.Primitive {
+	evicted := []tview.Primitive{}
+	for elem := C.order.Back(); elem != C.order.Front() && C.order.Len() > C.max; {
+		prev := elem.Prev()
+		cp := elem.Value.(*cachedPage)
+		if cp.page != shown {
+			C.order.Remove(elem)
+			delete(C.pages, cp.key)
+			evicted = append(evicted, cp.page)
+		}
+		elem = prev
+	}
+	return evicted
+}
+
+func (R *Router) shown() tview.Primitive {
+	R.histMu.Lock()
+	defer R.histMu.Unlock()
+	return R.layout
+}
+
+func varsValues(vars map[string]string) map[string][]string {
+	values := map[string][]string{}
+	for k, v := range vars {
+		values[k] = []string{v}
+	}
+	return values
+}
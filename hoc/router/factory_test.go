@@ -0,0 +1,63 @@
+package router
+
+import (
+	"container/list"
+	"reflect"
+	"testing"
+
+	"github.com/verdverm/tview"
+)
+
+func TestPageCacheEvict(t *testing.T) {
+	tests := []struct {
+		visits  []string // in order, the last is the most recent
+		max     int
+		shown   string
+		evicted []string
+		kept    []string // most recent first
+	}{
+		{[]string{"a", "b"}, 2, "", []string{}, []string{"b", "a"}},
+		{[]string{"a", "b", "c"}, 2, "", []string{"a"}, []string{"c", "b"}},
+		{[]string{"a", "b", "c", "d"}, 2, "c", []string{"a", "b"}, []string{"d", "c"}},
+		{[]string{"a", "b", "c"}, 2, "a", []string{"b"}, []string{"c", "a"}},
+		{[]string{"a", "b", "c", "d"}, 1, "a", []string{"b", "c"}, []string{"d", "a"}},
+		{[]string{"a", "b"}, 1, "b", []string{"a"}, []string{"b"}},
+	}
+
+	for i, tt := range tests {
+		C := &pageCache{
+			max:   tt.max,
+			order: list.New(),
+			pages: make(map[string]*list.Element),
+		}
+		pages := map[tview.Primitive]string{}
+		var shown tview.Primitive
+		for _, key := range tt.visits {
+			page := tview.NewBox()
+			pages[page] = key
+			if key == tt.shown {
+				shown = page
+			}
+			C.pages[key] = C.order.PushFront(&cachedPage{key, page})
+		}
+
+		evicted := []string{}
+		for _, page := range C.evict(shown) {
+			evicted = append(evicted, pages[page])
+		}
+		kept := []string{}
+		for elem := C.order.Front(); elem != nil; elem = elem.Next() {
+			kept = append(kept, elem.Value.(*cachedPage).key)
+		}
+
+		if !reflect.DeepEqual(evicted, tt.evicted) {
+			t.Errorf("%d: evicted %v, want %v", i, evicted, tt.evicted)
+		}
+		if !reflect.DeepEqual(kept, tt.kept) {
+			t.Errorf("%d: kept %v, want %v", i, kept, tt.kept)
+		}
+		if len(C.pages) != len(kept) {
+			t.Errorf("%d: %d pages in the map, want %d", i, len(C.pages), len(kept))
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package router
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/verdverm/tview"
+
+	"github.com/verdverm/vermui/events"
+	"github.com/verdverm/vermui/mux"
+)
+
+// ErrCancelled cancels a navigation without telling the user,
+// for guards and Leavers which already did, with a dialog say.
+var ErrCancelled = errors.New("navigation cancelled")
+
+// Guard runs before a route activates, from is nil for the first
+// navigation. It allows the navigation by returning "" and nil,
+// redirects it by returning a path, or cancels it with an error.
+type Guard func(to, from *Entry) (redirect string, err error)
+
+// Leaver is implemented by layouts which can veto navigating away,
+// say to confirm discarding unsaved changes. Navigating with "force"
+// set to true in the context skips the check, for after confirming.
+type Leaver interface {
+	CanLeave(to *Entry) error
+}
+
+type guardPair struct {
+	path  string
+	guard Guard
+}
+
+// AddGuard adds a guard for the route registered with the path
+// template, or for every route if path is "". They run in the order
+// they were added, global or not.
+func (R *Router) AddGuard(path string, guard Guard) {
+	R.histMu.Lock()
+	defer R.histMu.Unlock()
+	R.guards = append(R.guards, guardPair{path, guard})
+}
+
+// canLeave asks the current layout if it may be left for entry.
+func (R *Router) canLeave(entry *Entry, context map[string]interface{}) error {
+	if force, _ := context["force"].(bool); force {
+		return nil
+	}
+
+	R.histMu.Lock()
+	current := R.layout
+	R.histMu.Unlock()
+
+	if leaver, ok := current.(Leaver); ok {
+		return leaver.CanLeave(entry)
+	}
+	return nil
+}
+
+// guard runs the guards for the route req matched.
+func (R *Router) guard(entry *Entry, req *mux.Request) (string, error) {
+	tpl := ""
+	if route := mux.CurrentRoute(req); route != nil {
+		tpl, _ = route.GetPathTemplate()
+	}
+
+	R.histMu.Lock()
+	from := R.current
+	guards := []Guard{}
+	for _, gp := range R.guards {
+		if gp.path == "" || (tpl != "" && gp.path == tpl) {
+			guards = append(guards, gp.guard)
+		}
+	}
+	R.histMu.Unlock()
+
+	for _, guard := range guards {
+		redirect, err := guard(entry, from)
+		if err != nil || redirect != "" {
+			return redirect, err
+		}
+	}
+	return "", nil
+}
+
+// cancelled reports a navigation a guard or Leaver stopped.
+func (R *Router) cancelled(path string, err error) {
+	go events.SendCustomEvent("/router/cancelled", path)
+	if err == ErrCancelled {
+		return
+	}
+	msg := fmt.Sprintf("can't navigate to %s: %v", path, err)
+	go events.SendCustomEvent("/user/error", msg)
+	go events.SendCustomEvent("/console/warn", msg)
+}
+
+func (R *Router) setLayout(layout tview.Primitive) {
+	R.histMu.Lock()
+	defer R.histMu.Unlock()
+	R.layout = layout
+}
@@ -0,0 +1,161 @@
+package router
+
+import (
+	"net/url"
+
+	"github.com/verdverm/vermui"
+	"github.com/verdverm/vermui/events"
+	"github.com/verdverm/vermui/mux"
+)
+
+var (
+	// BackKey and ForwardKey move through the navigation history,
+	// set them before calling New.
+	BackKey    = "A-<left>"
+	ForwardKey = "A-<right>"
+
+	// MaxHistory bounds the entries kept each way.
+	MaxHistory = 100
+)
+
+// Entry is a place in the navigation history, with the
+// route vars and query parameters it was visited with.
+type Entry struct {
+	Path    string
+	Vars    map[string]string
+	Queries map[string][]string
+
+	// The vars with a converter, "{id:int}", as typed values.
+	Typed map[string]interface{}
+
+	// The path template of the route, "" if none matched.
+	Template string
+
+	// How it was reached, "dispatch", "back", "forward"
+	// or what the caller of SetActive put in "activation".
+	Source string
+}
+
+// URL is the path with the query parameters, to dispatch again.
+func (E *Entry) URL() string {
+	if len(E.Queries) == 0 {
+		return E.Path
+	}
+	return E.Path + "?" + url.Values(E.Queries).Encode()
+}
+
+func newEntry(req *mux.Request, context map[string]interface{}) *Entry {
+	E := &Entry{
+		Path:    req.Path,
+		Vars:    map[string]string{},
+		Queries: map[string][]string{},
+	}
+	for k, v := range mux.Vars(req) {
+		E.Vars[k] = v
+	}
+	if typed := mux.TypedVars(req); typed != nil {
+		E.Typed = map[string]interface{}{}
+		for k, v := range typed {
+			E.Typed[k] = v
+		}
+	}
+	for k, v := range req.Queries {
+		E.Queries[k] = append([]string{}, v...)
+	}
+	if route := mux.CurrentRoute(req); route != nil {
+		E.Template, _ = route.GetPathTemplate()
+	}
+	if src, ok := context["activation"].(string); ok {
+		E.Source = src
+	}
+	return E
+}
+
+func (R *Router) addHistoryHandlers() {
+	back := func(e events.Event) { R.Back() }
+	forward := func(e events.Event) { R.Forward() }
+
+	vermui.AddWidgetHandler(R.Pages, "/router/back", back)
+	vermui.AddWidgetHandler(R.Pages, "/router/forward", forward)
+	vermui.AddWidgetHandler(R.Pages, "/sys/key/"+BackKey, back)
+	vermui.AddWidgetHandler(R.Pages, "/sys/key/"+ForwardKey, forward)
+}
+
+// record makes entry the current one, after a navigation
+// which was not through the history.
+func (R *Router) record(entry *Entry) {
+	R.histMu.Lock()
+	if R.current != nil {
+		R.back = append(R.back, R.current)
+		if len(R.back) > MaxHistory {
+			R.back = R.back[len(R.back)-MaxHistory:]
+		}
+	}
+	R.current = entry
+	R.forward = nil
+	R.histMu.Unlock()
+
+	go events.SendCustomEvent("/router/changed", entry)
+}
+
+// Back returns to the previous entry in the history.
+func (R *Router) Back() bool {
+	return R.step(-1)
+}
+
+// Forward goes to the next entry, after going Back.
+func (R *Router) Forward() bool {
+	return R.step(1)
+}
+
+func (R *Router) step(dir int) bool {
+	R.histMu.Lock()
+	from := &R.back
+	to := &R.forward
+	source := "back"
+	if dir > 0 {
+		from, to = to, from
+		source = "forward"
+	}
+	if len(*from) == 0 {
+		R.histMu.Unlock()
+		return false
+	}
+	target := (*from)[len(*from)-1]
+	R.histMu.Unlock()
+
+	context := map[string]interface{}{
+		"activation": source,
+		"path":       target.URL(),
+	}
+	entry, ok := R.navigate(target.URL(), context)
+	if !ok {
+		return false
+	}
+
+	R.histMu.Lock()
+	*from = (*from)[:len(*from)-1]
+	if R.current != nil {
+		*to = append(*to, R.current)
+	}
+	R.current = entry
+	R.histMu.Unlock()
+
+	go events.SendCustomEvent("/router/changed", entry)
+	return true
+}
+
+// Current is the entry being shown, nil before the first navigation.
+func (R *Router) Current() *Entry {
+	R.histMu.Lock()
+	defer R.histMu.Unlock()
+	return R.current
+}
+
+// History returns the entries behind and ahead of the current one,
+// both ordered nearest last.
+func (R *Router) History() (back, forward []*Entry) {
+	R.histMu.Lock()
+	defer R.histMu.Unlock()
+	return append([]*Entry{}, R.back...), append([]*Entry{}, R.forward...)
+}
@@ -1,6 +1,11 @@
 package router
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
 	"github.com/pkg/errors"
 	"github.com/verdverm/tview"
 
@@ -18,18 +23,39 @@ type Routable interface {
 	Routings() []RoutePair
 }
 
+// RouteAware is implemented by layouts which show different things
+// for the vars and query parameters of the route that activates them,
+// "/users/{id}" say. RouteActivated is called before the layout is
+// switched to, every time, also when it is already showing.
+type RouteAware interface {
+	RouteActivated(route *Entry)
+}
+
 type Router struct {
 	*tview.Pages
 
 	// internal router
 	iRouter *mux.Router
+
+	// navigation history
+	histMu  sync.Mutex
+	current *Entry
+	back    []*Entry
+	forward []*Entry
+
+	layout tview.Primitive // being shown
+	guards []guardPair
+
+	pagesMu sync.Mutex
+	pages   map[string]bool // ids of the pages added for routes
+	served  tview.Primitive // a page added by setActive, see there
+
+	kindsMu sync.Mutex
+	kinds   map[*mux.Route]string // what routes were added with
 }
 
 func New() *Router {
-	r := &Router{
-		Pages:   tview.NewPages(),
-		iRouter: mux.NewRouter(),
-	}
+	r := newRouter()
 
 	vermui.AddWidgetHandler(r.Pages, "/router/dispatch", func(ev events.Event) {
 		path := ev.Data.(*events.EventCustom).Data().(string)
@@ -42,15 +68,36 @@ func New() *Router {
 		r.SetActive(path, context)
 	})
 
+	vermui.AddWidgetHandler(r.Pages, "/router/deeplink", func(ev events.Event) {
+		path := ev.Data.(*events.EventCustom).Data().(string)
+		context := map[string]interface{}{
+			"activation": "deeplink",
+			"path":       path,
+			"event":      ev,
+		}
+		r.SetActive(path, context)
+	})
+	r.addHistoryHandlers()
+
 	return r
 }
 
+// newRouter is New without the event handlers.
+func newRouter() *Router {
+	return &Router{
+		Pages:   tview.NewPages(),
+		iRouter: mux.NewRouter(),
+		pages:   make(map[string]bool),
+		kinds:   make(map[*mux.Route]string),
+	}
+}
+
 func (R *Router) SetNotFound(layout tview.Primitive) {
 	handler := func(req *mux.Request) (tview.Primitive, *mux.Request, error) {
 		return layout, req, nil
 	}
 	R.iRouter.NotFoundHandler = mux.NewDefaultHandler(handler)
-	R.AddPage(layout.Id(), layout, true, false)
+	R.addPage(layout)
 }
 
 func (R *Router) AddRoute(path string, thing interface{}) error {
@@ -65,6 +112,9 @@ func (R *Router) AddRoute(path string, thing interface{}) error {
 	case mux.Handler:
 		R.AddRouteHandler(path, t)
 
+	case Factory:
+		return R.AddRouteFactory(path, t, 0)
+
 	default:
 		return errors.New("Unknown thing to be routed to...")
 	}
@@ -73,40 +123,237 @@ func (R *Router) AddRoute(path string, thing interface{}) error {
 }
 
 func (R *Router) AddRouteLayout(path string, layout tview.Primitive) error {
-	R.AddPage(layout.Id(), layout, true, false)
+	R.addPage(layout)
 	handler := func(req *mux.Request) (tview.Primitive, *mux.Request, error) {
 		return layout, req, nil
 	}
-	R.iRouter.Handle(path, mux.NewDefaultHandler(handler))
+	route := R.iRouter.Handle(path, mux.NewDefaultHandler(handler))
+	R.setKind(route, fmt.Sprintf("%T", layout))
 	return nil
 }
 
 func (R *Router) AddRouteHandlerFunc(path string, handler mux.HandlerFunc) error {
-	R.iRouter.Handle(path, mux.NewDefaultHandler(handler))
+	route := R.iRouter.Handle(path, mux.NewDefaultHandler(handler))
+	R.setKind(route, "mux.HandlerFunc")
 	return nil
 }
 
 func (R *Router) AddRouteHandler(path string, handler mux.Handler) error {
-	R.iRouter.Handle(path, handler)
+	route := R.iRouter.Handle(path, handler)
+	R.setKind(route, fmt.Sprintf("%T", handler))
 	return nil
 }
 
+// AddRedirect sends navigation from one path to another, keeping old
+// paths in saved histories and scripts working, see mux.Router.Redirect.
+func (R *Router) AddRedirect(from, to string) error {
+	route := R.iRouter.Redirect(from, to)
+	R.setKind(route, "-> "+to)
+	return errors.Wrap(route.GetError(), "in AddRedirect")
+}
+
+// AddAlias adds another path for the route named name, see mux.Router.Alias.
+func (R *Router) AddAlias(from, name string) error {
+	route := R.iRouter.Alias(from, name)
+	R.setKind(route, "alias of "+name)
+	return errors.Wrap(route.GetError(), "in AddAlias")
+}
+
+// Use adds a middleware for the routes, see mux.MiddlewareFunc.
+// A middleware can serve a layout of its own, wrapping the one
+// of the route, it is shown like the route's.
+func (R *Router) Use(mwf mux.MiddlewareFunc) {
+	R.iRouter.Use(mwf)
+}
+
+// SetActive navigates to path, recording it in the history.
 func (R *Router) SetActive(path string, context map[string]interface{}) {
-	layout, req, err := R.iRouter.Dispatch(path, context)
-	if err != nil {
-		go events.SendCustomEvent("/console/error", errors.Wrap(err, "in dispatch handler"))
+	if entry, ok := R.navigate(path, context); ok {
+		R.record(entry)
 	}
-	if layout != nil {
+}
+
+// navigate resolves path and, unless the current layout or a guard
+// objects, serves it and shows the layout, returning the entry for the
+// history. Nothing is served for a navigation which is stopped.
+func (R *Router) navigate(path string, context map[string]interface{}) (*Entry, bool) {
+	for redirects := 0; ; redirects++ {
+		req, match, err := R.iRouter.Resolve(path, context)
+		if err != nil {
+			R.dispatchError(err)
+			return nil, false
+		}
+
 		ctx := req.Context
+		entry := newEntry(req, ctx)
+
+		if redirects == 0 {
+			if err := R.canLeave(entry, ctx); err != nil {
+				R.cancelled(path, err)
+				return nil, false
+			}
+		}
+
+		redirect, err := R.guard(entry, req)
+		if err != nil {
+			R.cancelled(path, err)
+			return nil, false
+		}
+		if redirect != "" {
+			if redirects >= mux.MaxRedirects {
+				R.cancelled(path, errors.New("too many redirects"))
+				return nil, false
+			}
+			context = map[string]interface{}{
+				"activation": "redirect",
+				"path":       redirect,
+				"redirected": path,
+			}
+			path = redirect
+			continue
+		}
+
+		layout, req, err := R.iRouter.ServeMatch(req, match)
+		if err != nil {
+			R.dispatchError(err)
+		}
+		if layout == nil {
+			go events.SendCustomEvent("/console/error", "nil layout in dispatch handler")
+			return nil, false
+		}
+
 		req.Context = nil
 		ctx["req"] = req
-		R.setActive(layout, ctx)
-	} else {
-		go events.SendCustomEvent("/console/error", "nil layout in dispatch handler")
+		ctx["entry"] = entry
+		R.setActive(layout, entry, ctx)
+		return entry, true
 	}
 }
 
-func (R *Router) setActive(layout tview.Primitive, context map[string]interface{}) {
+// dispatchError reports an error resolving or serving a path.
+func (R *Router) dispatchError(err error) {
+	go events.SendCustomEvent("/console/error", errors.Wrap(err, "in dispatch handler"))
+	// the user can fix the path they typed
+	if _, ok := errors.Cause(err).(*mux.VarError); ok || errors.Cause(err) == mux.ErrRedirectLoop {
+		go events.SendCustomEvent("/user/error", err.Error())
+	}
+}
+
+// setActive shows layout. A middleware can serve a layout of its own,
+// wrapping the one of the route, which is then added as a page while
+// it is shown and removed when another one is.
+func (R *Router) setActive(layout tview.Primitive, entry *Entry, context map[string]interface{}) {
+	if ra, ok := layout.(RouteAware); ok {
+		ra.RouteActivated(entry)
+	}
+
+	R.pagesMu.Lock()
+	old := R.served
+	R.served = nil
+	if !R.pages[layout.Id()] {
+		R.served = layout
+	}
+	add := R.served != nil && layout != old
+	R.pagesMu.Unlock()
+
+	if add {
+		R.AddPage(layout.Id(), layout, true, false)
+	}
+	R.setLayout(layout)
 	R.Pages.SwitchToPage(layout.Id(), context)
+	if old != nil && old != layout {
+		old.Unmount()
+		R.RemovePage(old.Id())
+	}
 	vermui.Draw()
 }
+
+// addPage adds the page of a route.
+func (R *Router) addPage(layout tview.Primitive) {
+	R.pagesMu.Lock()
+	R.pages[layout.Id()] = true
+	R.pagesMu.Unlock()
+	R.AddPage(layout.Id(), layout, true, false)
+}
+
+// removePage removes the page of a route.
+func (R *Router) removePage(layout tview.Primitive) {
+	R.pagesMu.Lock()
+	delete(R.pages, layout.Id())
+	R.pagesMu.Unlock()
+	R.RemovePage(layout.Id())
+}
+
+// RouteInfo describes a registered route.
+type RouteInfo struct {
+	Name    string
+	Path    string
+	Queries []string // query templates, "tab={tab}"
+	Vars    []string // of the path and queries
+	Handler string   // what the route was added with
+}
+
+// Routes returns the registered routes, in the order they were added.
+func (R *Router) Routes() []RouteInfo {
+	infos := []RouteInfo{}
+	R.iRouter.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		tpl, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		info := RouteInfo{
+			Name:    route.GetName(),
+			Path:    tpl,
+			Vars:    templateVars(tpl),
+			Handler: R.kind(route),
+		}
+		if queries, err := route.GetQueriesTemplates(); err == nil {
+			info.Queries = queries
+			for _, q := range queries {
+				info.Vars = append(info.Vars, templateVars(q)...)
+			}
+		}
+		infos = append(infos, info)
+		return nil
+	})
+	return infos
+}
+
+// NameRoute names the route registered with the path template,
+// named routes show up in navigation components.
+func (R *Router) NameRoute(path, name string) error {
+	var found *mux.Route
+	R.iRouter.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		if tpl, err := route.GetPathTemplate(); err == nil && tpl == path {
+			found = route
+		}
+		return nil
+	})
+	if found == nil {
+		return errors.Errorf("no route registered for %q", path)
+	}
+	return found.Name(name).GetError()
+}
+
+// CompletePath returns the registered path templates starting with prefix,
+// cut short at their first variable so it can be filled in.
+func (R *Router) CompletePath(prefix string) []string {
+	seen := map[string]bool{}
+	paths := []string{}
+	R.iRouter.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		tpl, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		if i := strings.Index(tpl, "{"); i >= 0 {
+			tpl = tpl[:i]
+		}
+		if strings.HasPrefix(tpl, prefix) && !seen[tpl] {
+			seen[tpl] = true
+			paths = append(paths, tpl)
+		}
+		return nil
+	})
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,49 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/verdverm/tview"
+
+	"github.com/verdverm/vermui/mux"
+)
+
+func TestDecoratedLayout(t *testing.T) {
+	R := newRouter()
+	plain := tview.NewBox()
+	page := tview.NewBox()
+	R.AddRouteLayout("/plain", plain)
+	R.AddRouteLayout("/page", page)
+
+	// wraps the layout of "/page", the same wrapper every time
+	wrapper := tview.NewBox()
+	R.Use(func(next mux.Handler) mux.Handler {
+		return mux.NewDefaultHandler(func(req *mux.Request) (tview.Primitive, *mux.Request, error) {
+			layout, req, err := next.Serve(req)
+			if layout == page {
+				layout = wrapper
+			}
+			return layout, req, err
+		})
+	})
+
+	steps := []struct {
+		path   string
+		shown  tview.Primitive
+		served tview.Primitive
+	}{
+		{"/page", wrapper, wrapper},
+		{"/page", wrapper, wrapper},
+		{"/plain", plain, nil},
+		{"/page", wrapper, wrapper},
+	}
+	for i, s := range steps {
+		R.SetActive(s.path, nil)
+		if R.shown() != s.shown {
+			t.Errorf("%d %s: shows %q, want %q", i, s.path, R.shown().Id(), s.shown.Id())
+		}
+		if R.served != s.served {
+			t.Errorf("%d %s: added page %v, want %v", i, s.path, R.served, s.served)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package router
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/verdverm/vermui/mux"
+)
+
+func (R *Router) setKind(route *mux.Route, kind string) {
+	R.kindsMu.Lock()
+	defer R.kindsMu.Unlock()
+	R.kinds[route] = kind
+}
+
+func (R *Router) kind(route *mux.Route) string {
+	R.kindsMu.Lock()
+	defer R.kindsMu.Unlock()
+	return R.kinds[route]
+}
+
+// Build fills in the vars of the route registered with the path
+// template, returning the path, with any queries, to dispatch.
+func (R *Router) Build(path string, vars map[string]string) (string, error) {
+	var found *mux.Route
+	R.iRouter.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		if tpl, err := route.GetPathTemplate(); err == nil && tpl == path && found == nil {
+			found = route
+		}
+		return nil
+	})
+	if found == nil {
+		return "", errors.Errorf("no route registered for %q", path)
+	}
+
+	pairs := []string{}
+	for k, v := range vars {
+		pairs = append(pairs, k, v)
+	}
+	built, err := found.Substitute(pairs...)
+	if err != nil {
+		return "", errors.Wrapf(err, "building %q", path)
+	}
+	return strings.TrimSuffix(built, "?"), nil
+}
+
+// templateVars returns the names of the {vars} in a template,
+// which may have patterns with braces of their own.
+func templateVars(tpl string) []string {
+	vars := []string{}
+	level, start := 0, 0
+	for i := 0; i < len(tpl); i++ {
+		switch tpl[i] {
+		case '{':
+			if level == 0 {
+				start = i + 1
+			}
+			level++
+		case '}':
+			level--
+			if level == 0 {
+				name := tpl[start:i]
+				if j := strings.Index(name, ":"); j >= 0 {
+					name = name[:j]
+				}
+				vars = append(vars, strings.TrimSpace(name))
+			}
+		}
+	}
+	return vars
+}
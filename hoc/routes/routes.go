@@ -0,0 +1,193 @@
+// Package routes lists the routes of a router, to look them up and
+// navigate to them, filling in any route variables in a form.
+package routes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell"
+	"github.com/verdverm/tview"
+
+	"github.com/verdverm/vermui"
+	"github.com/verdverm/vermui/events"
+	"github.com/verdverm/vermui/hoc/cmdbox"
+	"github.com/verdverm/vermui/hoc/router"
+)
+
+const (
+	overlayName = "routes"
+	formOverlay = "routes-vars"
+)
+
+var header = []string{"path", "name", "queries", "handler"}
+
+// RoutesView is a table of the routes of a router. Enter on a
+// route navigates to it, asking for its variables first if it has any.
+type RoutesView struct {
+	*tview.Table
+
+	router *router.Router
+	infos  []router.RouteInfo
+
+	// Done is called after navigating or on Escape, optional.
+	Done func()
+}
+
+func New(r *router.Router) *RoutesView {
+	V := &RoutesView{
+		Table:  tview.NewTable(),
+		router: r,
+	}
+
+	V.Table.
+		SetSelectable(true, false).
+		SetFixed(1, 0).
+		SetSelectedFunc(func(row, column int) {
+			if row > 0 && row-1 < len(V.infos) {
+				V.open(V.infos[row-1])
+			}
+		}).
+		SetDoneFunc(func(key tcell.Key) {
+			if key == tcell.KeyEscape {
+				V.done()
+			}
+		})
+	V.SetTitle(" routes ").SetBorder(true)
+
+	V.Refresh()
+	return V
+}
+
+// Refresh reloads the routes, sorted by path.
+func (V *RoutesView) Refresh() {
+	V.infos = V.router.Routes()
+	sort.SliceStable(V.infos, func(i, j int) bool {
+		return V.infos[i].Path < V.infos[j].Path
+	})
+
+	cells := [][]*tview.TableCell{{}}
+	for _, h := range header {
+		cells[0] = append(cells[0], tview.NewTableCell(strings.ToUpper(h)).
+			SetTextColor(tcell.ColorYellow).
+			SetSelectable(false))
+	}
+	for _, row := range Rows(V.infos) {
+		line := []*tview.TableCell{}
+		for _, text := range row {
+			line = append(line, tview.NewTableCell(text))
+		}
+		cells = append(cells, line)
+	}
+	V.Table.SetCells(cells)
+	V.Select(1, 0)
+}
+
+// Rows formats routes as text, in the order of the table columns.
+func Rows(infos []router.RouteInfo) [][]string {
+	rows := [][]string{}
+	for _, info := range infos {
+		rows = append(rows, []string{
+			info.Path,
+			info.Name,
+			strings.Join(info.Queries, "&"),
+			info.Handler,
+		})
+	}
+	return rows
+}
+
+// CopyText is the path template of the selected route,
+// "/users/{id}" rather than a path built from it.
+func (V *RoutesView) CopyText() string {
+	row, _ := V.GetSelection()
+	if row < 1 || row-1 >= len(V.infos) {
+		return ""
+	}
+	return V.infos[row-1].Path
+}
+
+// open navigates to the route, through the form if it has vars.
+func (V *RoutesView) open(info router.RouteInfo) {
+	if len(info.Vars) == 0 {
+		V.done()
+		go events.SendCustomEvent("/router/dispatch", info.Path)
+		return
+	}
+	V.showForm(info)
+}
+
+// showForm asks for the vars of the route, then navigates.
+func (V *RoutesView) showForm(info router.RouteInfo) {
+	values := map[string]string{}
+	form := tview.NewForm()
+	for _, name := range info.Vars {
+		name := name
+		values[name] = ""
+		form.AddInputField(name, "", 30, nil, func(text string) {
+			values[name] = text
+		})
+	}
+
+	hide := func() {
+		vermui.HideOverlay(formOverlay)
+		vermui.SetFocus(V)
+	}
+	form.AddButton("Go", func() {
+		path, err := V.router.Build(info.Path, values)
+		if err != nil {
+			go events.SendCustomEvent("/user/error", err.Error())
+			return
+		}
+		vermui.HideOverlay(formOverlay)
+		V.done()
+		go events.SendCustomEvent("/router/dispatch", path)
+	})
+	form.AddButton("Cancel", hide)
+	form.SetTitle(" " + info.Path + " ").SetBorder(true)
+
+	vermui.ShowOverlay(formOverlay, form, vermui.Centered(50, 2*len(info.Vars)+5))
+	vermui.SetFocus(form)
+}
+
+func (V *RoutesView) done() {
+	if V.Done != nil {
+		V.Done()
+	}
+}
+
+// Command is a "routes" command for a command box. It shows the
+// table in an overlay, or with --list writes the routes as lines,
+// to pipe or redirect them.
+func Command(r *router.Router) cmdbox.Command {
+	return &cmdbox.SpecCommand{
+		Name: "routes",
+		Help: "show the routes, Enter navigates to one",
+		Spec: &cmdbox.ArgSpec{
+			Flags: []cmdbox.Flag{
+				{Name: "list", Short: "l", Type: cmdbox.BoolArg, Help: "write the routes instead"},
+			},
+		},
+		Callback: func(args *cmdbox.Args, context map[string]interface{}) {
+			if args.Bool("list") {
+				ex := cmdbox.ExecFromContext(context)
+				if ex == nil {
+					return
+				}
+				for _, row := range Rows(r.Routes()) {
+					fmt.Fprintln(ex.Out, strings.Join(row, "\t"))
+				}
+				return
+			}
+
+			V := New(r)
+			V.Done = func() {
+				vermui.HideOverlay(overlayName)
+				vermui.Unfocus()
+			}
+			vermui.ShowOverlay(overlayName, V, vermui.Centered(100, 24))
+			vermui.SetFocus(V)
+		},
+	}
+}
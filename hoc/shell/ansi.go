@@ -0,0 +1,144 @@
+package shell
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gdamore/tcell"
+)
+
+var (
+	csiPattern = regexp.MustCompile("\x1b\\[([0-9;?]*)([A-Za-z])")
+	tagPattern = regexp.MustCompile(`(\[[a-zA-Z0-9_,;: \-\.#]*)\]`)
+)
+
+// the 16 ANSI colors, as tview names them
+var ansiColors = []string{
+	"black", "maroon", "green", "olive", "navy", "purple", "teal", "silver",
+	"gray", "red", "lime", "yellow", "blue", "fuchsia", "aqua", "white",
+}
+
+// ansiWriter translates the SGR color codes of terminal output into
+// tview color tags, a line at a time. Other escape sequences are dropped.
+type ansiWriter struct {
+	w io.Writer
+
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	fg, bg string
+}
+
+func newANSIWriter(w io.Writer) *ansiWriter {
+	return &ansiWriter{w: w, fg: "-", bg: "-"}
+}
+
+func (A *ansiWriter) Write(p []byte) (int, error) {
+	A.mu.Lock()
+	defer A.mu.Unlock()
+
+	A.buf.Write(p)
+	for {
+		i := bytes.IndexByte(A.buf.Bytes(), '\n')
+		if i < 0 {
+			break
+		}
+		line := string(A.buf.Next(i + 1))
+		if _, err := io.WriteString(A.w, A.translate(line)); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// flush writes a last line without a newline.
+func (A *ansiWriter) flush() error {
+	A.mu.Lock()
+	defer A.mu.Unlock()
+
+	line := A.buf.String()
+	A.buf.Reset()
+	if line == "" {
+		return nil
+	}
+	_, err := io.WriteString(A.w, A.translate(line)+"\n")
+	return err
+}
+
+func (A *ansiWriter) translate(line string) string {
+	line = strings.Replace(line, "\r", "", -1)
+
+	var out bytes.Buffer
+	last := 0
+	for _, m := range csiPattern.FindAllStringSubmatchIndex(line, -1) {
+		out.WriteString(tagPattern.ReplaceAllString(line[last:m[0]], "$1[]"))
+		last = m[1]
+		if line[m[4]:m[5]] == "m" {
+			A.sgr(line[m[2]:m[3]])
+			fmt.Fprintf(&out, "[%s:%s]", A.fg, A.bg)
+		}
+	}
+	out.WriteString(tagPattern.ReplaceAllString(line[last:], "$1[]"))
+	return out.String()
+}
+
+// sgr updates the colors for the parameters of a "Select Graphic Rendition".
+func (A *ansiWriter) sgr(params string) {
+	codes := []int{}
+	for _, p := range strings.Split(params, ";") {
+		n, _ := strconv.Atoi(p) // empty is 0
+		codes = append(codes, n)
+	}
+
+	for i := 0; i < len(codes); i++ {
+		c := codes[i]
+		switch {
+		case c == 0:
+			A.fg, A.bg = "-", "-"
+		case c >= 30 && c <= 37:
+			A.fg = ansiColors[c-30]
+		case c >= 90 && c <= 97:
+			A.fg = ansiColors[c-90+8]
+		case c == 39:
+			A.fg = "-"
+		case c >= 40 && c <= 47:
+			A.bg = ansiColors[c-40]
+		case c >= 100 && c <= 107:
+			A.bg = ansiColors[c-100+8]
+		case c == 49:
+			A.bg = "-"
+		case c == 38 || c == 48:
+			color, n := extendedColor(codes[i+1:])
+			i += n
+			if color == "" {
+				continue
+			}
+			if c == 38 {
+				A.fg = color
+			} else {
+				A.bg = color
+			}
+		}
+	}
+}
+
+// extendedColor reads "5;n" or "2;r;g;b", returning
+// the color and how many codes it used.
+func extendedColor(codes []int) (string, int) {
+	if len(codes) >= 2 && codes[0] == 5 {
+		n := codes[1]
+		if n < 16 {
+			return ansiColors[n], 2
+		}
+		r, g, b := tcell.Color(n).RGB()
+		return fmt.Sprintf("#%02x%02x%02x", r, g, b), 2
+	}
+	if len(codes) >= 4 && codes[0] == 2 {
+		return fmt.Sprintf("#%02x%02x%02x", codes[1]&0xff, codes[2]&0xff, codes[3]&0xff), 4
+	}
+	return "", len(codes)
+}
@@ -0,0 +1,30 @@
+package shell
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestANSIWriter(t *testing.T) {
+	tests := []struct {
+		input, output string
+	}{
+		{"plain\n", "plain\n"},
+		{"\x1b[31mred\x1b[0m ok\n", "[maroon:-]red[-:-] ok\n"},
+		{"\x1b[1;92;44mhi\x1b[39m there\n", "[lime:navy]hi[-:navy] there\n"},
+		{"\x1b[38;5;9mx\x1b[38;2;255;0;16my\n", "[red:-]x[#ff0010:-]y\n"},
+		{"\x1b[2K\x1b[1Gprogress\r\n", "progress\n"},
+		{"a [tag] b\n", "a [tag[] b\n"},
+		{"no newline", "no newline\n"},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		w := newANSIWriter(&out)
+		w.Write([]byte(test.input))
+		w.flush()
+		if out.String() != test.output {
+			t.Errorf("%q: expected %q, got %q", test.input, test.output, out.String())
+		}
+	}
+}
@@ -0,0 +1,184 @@
+// Package shell is a widget which runs external commands
+// and shows their output as it comes.
+package shell
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/verdverm/tview"
+
+	"github.com/verdverm/vermui"
+	"github.com/verdverm/vermui/events"
+)
+
+// ShellWidget runs a command line in a shell, one at a time, streaming
+// stdout and stderr into a scrollable view with the ANSI colors kept.
+//
+// It runs the command line sent with "/shell/run", the command box
+// sends the input after a leading '!' there. "/shell/kill" or the
+// KillKey stop it.
+type ShellWidget struct {
+	*tview.TextView
+
+	// Shell runs the command lines, with "-c", defaults to $SHELL or /bin/sh.
+	Shell string
+
+	// KillKey stops the running command, set before Mount.
+	KillKey string
+
+	mu   sync.Mutex
+	line string
+	cmd  *exec.Cmd // running
+}
+
+func New() *ShellWidget {
+	textView := tview.NewTextView().
+		SetScrollable(true).
+		SetDynamicColors(true).
+		SetChangedFunc(func() {
+			vermui.Draw()
+		})
+
+	textView.SetTitle(" shell ").SetBorder(true)
+
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+
+	return &ShellWidget{
+		TextView: textView,
+		Shell:    shell,
+		KillKey:  "C-x",
+	}
+}
+
+func (S *ShellWidget) Mount(context map[string]interface{}) error {
+	vermui.AddWidgetHandler(S, "/shell/run", func(e events.Event) {
+		ec, ok := e.Data.(*events.EventCustom)
+		if !ok {
+			return
+		}
+		line, ok := ec.Data().(string)
+		if !ok {
+			return
+		}
+		if err := S.Run(line); err != nil {
+			go events.SendCustomEvent("/user/error", err.Error())
+		}
+	})
+
+	vermui.AddWidgetHandler(S, "/shell/kill", func(e events.Event) {
+		S.Kill()
+	})
+
+	vermui.AddWidgetHandler(S, "/sys/key/"+S.KillKey, func(e events.Event) {
+		S.mu.Lock()
+		line := S.line
+		S.mu.Unlock()
+		if S.Kill() {
+			go events.SendCustomEvent("/status/message", "killed "+line)
+		}
+	})
+
+	return nil
+}
+
+func (S *ShellWidget) Unmount() error {
+	vermui.RemoveWidgetHandler(S, "/shell/run")
+	vermui.RemoveWidgetHandler(S, "/shell/kill")
+	vermui.RemoveWidgetHandler(S, "/sys/key/"+S.KillKey)
+
+	S.Kill()
+	return nil
+}
+
+// Run starts line in the shell, clearing the previous output.
+// Only one command runs at a time.
+func (S *ShellWidget) Run(line string) error {
+	S.mu.Lock()
+	defer S.mu.Unlock()
+
+	if S.cmd != nil {
+		return fmt.Errorf("shell: %q is still running", S.line)
+	}
+
+	cmd := exec.Command(S.Shell, "-c", line)
+	setProcessGroup(cmd)
+
+	// the same writer for both, so they are written one at a time
+	out := newANSIWriter(S.TextView)
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	S.Clear()
+	S.setTitle(line, "running")
+
+	started := time.Now()
+	if err := cmd.Start(); err != nil {
+		S.setTitle(line, "failed")
+		return errors.Wrap(err, "in shell.Run")
+	}
+	S.line = line
+	S.cmd = cmd
+
+	go events.SendCustomEvent("/shell/started", line)
+
+	go func() {
+		err := cmd.Wait()
+		out.flush()
+		elapsed := time.Since(started)
+
+		S.mu.Lock()
+		S.cmd = nil
+		S.mu.Unlock()
+
+		status := "exit 0"
+		if err != nil {
+			status = err.Error() // "exit status 1", "signal: killed"
+		}
+		S.setTitle(line, fmt.Sprintf("%s, %s", status, elapsed.Round(time.Millisecond)))
+		vermui.Draw()
+
+		events.SendCustomEvent("/shell/done", fmt.Sprintf("%s (%s)", line, status))
+	}()
+
+	return nil
+}
+
+// Kill stops the running command, if there is one,
+// along with the commands the shell started.
+func (S *ShellWidget) Kill() bool {
+	S.mu.Lock()
+	defer S.mu.Unlock()
+
+	if S.cmd == nil {
+		return false
+	}
+	if err := killGroup(S.cmd); err != nil {
+		go events.SendCustomEvent("/console/warn", fmt.Sprintf("shell: %v", err))
+	}
+	return true
+}
+
+// Running reports whether a command is running.
+func (S *ShellWidget) Running() bool {
+	S.mu.Lock()
+	defer S.mu.Unlock()
+	return S.cmd != nil
+}
+
+// CopyText is the output of the last command as plain text,
+// with the ANSI colors it was translated to stripped again.
+func (S *ShellWidget) CopyText() string {
+	return S.GetText(true)
+}
+
+func (S *ShellWidget) setTitle(line, status string) {
+	S.SetTitle(fmt.Sprintf(" $ %s (%s) ", tagPattern.ReplaceAllString(line, "$1[]"), status))
+}
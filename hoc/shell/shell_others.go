@@ -0,0 +1,21 @@
+//go:build !windows
+// +build !windows
+
+package shell
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup runs the shell in a process group of its own,
+// with the commands it starts.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killGroup kills the shell and the commands it started, which
+// would otherwise keep the output open, and Wait waiting, until done.
+func killGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,37 @@
+//go:build !windows
+// +build !windows
+
+package shell
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestKillGroup(t *testing.T) {
+	cmd := exec.Command("/bin/sh", "-c", "sleep 3; echo done")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	// let the shell start sleep, which keeps the output open
+	time.Sleep(100 * time.Millisecond)
+
+	killed := time.Now()
+	if err := killGroup(cmd); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Wait(); err == nil {
+		t.Error("expected the shell to be killed")
+	}
+	if elapsed := time.Since(killed); elapsed > time.Second {
+		t.Errorf("Wait returned %s after the kill", elapsed)
+	}
+	if out.Len() > 0 {
+		t.Errorf("expected no output, got %q", out.String())
+	}
+}
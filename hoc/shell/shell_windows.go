@@ -0,0 +1,15 @@
+//go:build windows
+// +build windows
+
+package shell
+
+import (
+	"os/exec"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killGroup kills the shell only, there are no process groups to kill.
+func killGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
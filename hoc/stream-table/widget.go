@@ -1,6 +1,8 @@
 package streamtable
 
 import (
+	"strings"
+
 	"github.com/verdverm/tview"
 	"github.com/verdverm/vermui"
 )
@@ -86,3 +88,22 @@ func (ST *StreamTable) UpdateData(input interface{}) {
 
 	vermui.Draw()
 }
+
+// CopyText joins the cells of the selected row with tabs,
+// so the row pastes into a spreadsheet as one.
+func (ST *StreamTable) CopyText() string {
+	row, _ := ST.GetSelection()
+	if row < 0 || row >= ST.GetRowCount() {
+		return ""
+	}
+
+	cells := []string{}
+	for c := 0; c < ST.GetColumnCount(); c++ {
+		text := ""
+		if cell := ST.GetCell(row, c); cell != nil {
+			text = cell.Text
+		}
+		cells = append(cells, text)
+	}
+	return strings.Join(cells, "\t")
+}
@@ -0,0 +1,168 @@
+package mux
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Converter turns the text of a route variable into a typed value.
+//
+// A variable uses a converter when its pattern is the name of one,
+// "{id:int}". The built-in "uuid" also converts "{uuid}", registered
+// converters are only used by name, so "{id}" stays untyped.
+type Converter struct {
+	// Pattern replaces the converter name in the route regexp,
+	// it must not have capturing groups. Text it does not match is
+	// not found, leave checking it to Convert for a *VarError, so
+	// the built-in ones match anything up to the next slash.
+	Pattern string
+
+	// Convert returns the typed value, an error fails the match.
+	Convert func(text string) (interface{}, error)
+}
+
+var (
+	convertersMu sync.RWMutex
+	converters   = map[string]Converter{
+		"int": {
+			Pattern: `[^/]+`,
+			Convert: func(text string) (interface{}, error) {
+				return strconv.Atoi(text)
+			},
+		},
+		"time": {
+			Pattern: `[^/]+`,
+			Convert: parseTime,
+		},
+		"uuid": {
+			Pattern: `[^/]+`,
+			Convert: func(text string) (interface{}, error) {
+				if !uuidRegexp.MatchString(text) {
+					return nil, fmt.Errorf("want 8-4-4-4-12 hex digits")
+				}
+				return strings.ToLower(text), nil
+			},
+		},
+	}
+)
+
+var uuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// RegisterConverter adds, or replaces, a converter. Routes added before
+// keep the converter they were built with.
+func RegisterConverter(name string, c Converter) {
+	convertersMu.Lock()
+	defer convertersMu.Unlock()
+	converters[name] = c
+}
+
+func lookupConverter(name string) (Converter, bool) {
+	convertersMu.RLock()
+	defer convertersMu.RUnlock()
+	c, ok := converters[name]
+	return c, ok
+}
+
+// parseTime reads RFC 3339 times, dates, or unix seconds.
+func parseTime(text string) (interface{}, error) {
+	if secs, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return time.Unix(secs, 0), nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("want an RFC 3339 time, a date or unix seconds")
+}
+
+// varConverter is the converter of a variable in a routeRegexp.
+type varConverter struct {
+	typ     string
+	convert func(string) (interface{}, error)
+}
+
+// VarError is the match error when a converter rejects a route variable.
+type VarError struct {
+	Name  string
+	Value string
+	Type  string
+	Err   error
+}
+
+func (e *VarError) Error() string {
+	return fmt.Sprintf("mux: route variable %q: %q is not a valid %s: %v", e.Name, e.Value, e.Type, e.Err)
+}
+
+// convert converts the matched variables which have a converter.
+func (v *routeRegexpGroup) convert(m *RouteMatch) error {
+	regexps := append([]*routeRegexp{v.path}, v.queries...)
+	for _, r := range regexps {
+		if r == nil {
+			continue
+		}
+		for i, c := range r.varsC {
+			if c == nil {
+				continue
+			}
+			name := r.varsN[i]
+			text, ok := m.Vars[name]
+			if !ok {
+				continue
+			}
+			value, err := c.convert(text)
+			if err != nil {
+				return &VarError{Name: name, Value: text, Type: c.typ, Err: err}
+			}
+			if m.Typed == nil {
+				m.Typed = make(map[string]interface{})
+			}
+			m.Typed[name] = value
+		}
+	}
+	return nil
+}
+
+// TypedVars returns the converted route variables for the current
+// request, only those with a converter are in it.
+func TypedVars(r *Request) map[string]interface{} {
+	if rv := r.Context[typedVarsKey]; rv != nil {
+		return rv.(map[string]interface{})
+	}
+	return nil
+}
+
+// Var returns the converted value of a route variable,
+// or its text if it has no converter, nil if there is none.
+func Var(r *Request, name string) interface{} {
+	if v, ok := TypedVars(r)[name]; ok {
+		return v
+	}
+	if v, ok := Vars(r)[name]; ok {
+		return v
+	}
+	return nil
+}
+
+// VarInt returns a route variable converted with "int".
+func VarInt(r *Request, name string) (int, bool) {
+	v, ok := TypedVars(r)[name].(int)
+	return v, ok
+}
+
+// VarTime returns a route variable converted with "time".
+func VarTime(r *Request, name string) (time.Time, bool) {
+	v, ok := TypedVars(r)[name].(time.Time)
+	return v, ok
+}
+
+func setTypedVars(r *Request, val map[string]interface{}) *Request {
+	if val != nil {
+		r.Context[typedVarsKey] = val
+	}
+	return r
+}
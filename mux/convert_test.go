@@ -0,0 +1,84 @@
+package mux
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/verdverm/tview"
+)
+
+func TestConverters(t *testing.T) {
+	RegisterConverter("even", Converter{
+		Pattern: `[0-9]+`,
+		Convert: func(text string) (interface{}, error) {
+			if (text[len(text)-1]-'0')%2 != 0 {
+				return nil, fmt.Errorf("odd")
+			}
+			return text, nil
+		},
+	})
+
+	var served *Request
+	handler := NewDefaultHandler(func(req *Request) (tview.Primitive, *Request, error) {
+		served = req
+		return nil, req, nil
+	})
+	r := NewRouter()
+	r.Handle("/jobs/{id:int}", handler)
+	r.Handle("/at/{ts:time}", handler)
+	r.Handle("/users/{uuid}", handler)
+	r.Handle("/even/{n:even}", handler)
+	r.Handle("/plain/{even}", handler)
+	r.Handle("/page", handler).Queries("page", "{page:int}")
+
+	tests := []struct {
+		path  string
+		name  string
+		value interface{}
+		err   string // the type of the rejected value, if any
+	}{
+		{"/jobs/42", "id", 42, ""},
+		{"/jobs/-7", "id", -7, ""},
+		{"/jobs/abc", "id", nil, "int"},
+		{"/jobs/99999999999999999999", "id", nil, "int"},
+		{"/at/1700000000", "ts", time.Unix(1700000000, 0), ""},
+		{"/at/2024-01-02", "ts", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), ""},
+		{"/at/2024-01-02T03:04:05Z", "ts", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), ""},
+		{"/at/yesterday", "ts", nil, "time"},
+		{"/users/123E4567-E89B-12D3-A456-426614174000", "uuid", "123e4567-e89b-12d3-a456-426614174000", ""},
+		{"/users/123", "uuid", nil, "uuid"},
+		{"/even/42", "n", "42", ""},
+		{"/even/43", "n", nil, "even"},
+		{"/plain/43", "even", "43", ""},
+		{"/page?page=3", "page", 3, ""},
+		{"/page?page=three", "page", nil, "int"},
+	}
+
+	for _, test := range tests {
+		served = nil
+		_, req, err := r.Dispatch(test.path, nil)
+		if test.err != "" {
+			verr, ok := err.(*VarError)
+			if !ok {
+				t.Errorf("Dispatch(%q): expected a *VarError, got %v", test.path, err)
+				continue
+			}
+			if verr.Name != test.name || verr.Type != test.err {
+				t.Errorf("Dispatch(%q): expected %s %s to be rejected, got %v", test.path, test.err, test.name, verr)
+			}
+			if served != nil {
+				t.Errorf("Dispatch(%q): the handler was served", test.path)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Dispatch(%q): unexpected error: %v", test.path, err)
+			continue
+		}
+		if got := Var(req, test.name); !reflect.DeepEqual(got, test.value) {
+			t.Errorf("Dispatch(%q): expected %s = %#v, got %#v", test.path, test.name, test.value, got)
+		}
+	}
+}
@@ -1,8 +1,21 @@
 package mux
 
-// MiddlewareFunc is a function which receives an http.Handler and returns another http.Handler.
-// Typically, the returned handler is a closure which does something with the http.ResponseWriter and http.Request passed
-// to it, and then calls the handler passed as parameter to the MiddlewareFunc.
+// MiddlewareFunc is a function which receives a Handler and returns another Handler.
+// Typically, the returned handler is a closure which does something with the Request passed
+// to it, calls the handler passed as parameter to the MiddlewareFunc, and then does something
+// with the primitive it produced, or returns a different one.
+//
+//	func timing(next mux.Handler) mux.Handler {
+//		return mux.NewDefaultHandler(func(req *mux.Request) (tview.Primitive, *mux.Request, error) {
+//			start := time.Now()
+//			p, req, err := next.Serve(req)
+//			log.Println(req.Path, time.Since(start))
+//			return p, req, err
+//		})
+//	}
+//
+// Middlewares run for requests matching a route of the Router they are used on, or of one of
+// its subrouters, not for the NotFoundHandler.
 type MiddlewareFunc func(Handler) Handler
 
 // middleware interface is anything which implements a MiddlewareFunc named Middleware.
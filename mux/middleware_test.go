@@ -0,0 +1,77 @@
+package mux
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/verdverm/tview"
+)
+
+// recorder records the middlewares and handlers serving requests.
+type recorder []string
+
+func (rec *recorder) middleware(name string) MiddlewareFunc {
+	return func(next Handler) Handler {
+		return NewDefaultHandler(func(req *Request) (tview.Primitive, *Request, error) {
+			*rec = append(*rec, name)
+			return next.Serve(req)
+		})
+	}
+}
+
+func (rec *recorder) handler(name string) Handler {
+	return NewDefaultHandler(func(req *Request) (tview.Primitive, *Request, error) {
+		*rec = append(*rec, name)
+		return nil, req, nil
+	})
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	rec := recorder{}
+	r := NewRouter()
+	r.Use(rec.middleware("a"))
+	r.Use(rec.middleware("b"))
+	r.Use(rec.middleware("c"))
+	r.Handle("/", rec.handler("h"))
+
+	if _, _, err := r.Dispatch("/", nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := (recorder{"a", "b", "c", "h"}); !reflect.DeepEqual(rec, want) {
+		t.Errorf("expected %q, got %q", want, rec)
+	}
+}
+
+func TestMiddlewareSubrouter(t *testing.T) {
+	rec := recorder{}
+	r := NewRouter()
+	r.Use(rec.middleware("outer"))
+	admin := r.PathPrefix("/admin").Subrouter()
+	admin.Use(rec.middleware("inner"))
+	admin.Handle("/users", rec.handler("users"))
+	r.Handle("/admin/other", rec.handler("other"))
+	r.Handle("/home", rec.handler("home"))
+	r.NotFoundHandler = rec.handler("not found")
+
+	tests := []struct {
+		path string
+		want recorder
+	}{
+		{"/admin/users", recorder{"outer", "inner", "users"}},
+		// the subrouter matches its prefix but none of its routes
+		{"/admin/other", recorder{"outer", "other"}},
+		{"/home", recorder{"outer", "home"}},
+		{"/nowhere", recorder{"not found"}},
+	}
+
+	for _, test := range tests {
+		rec = recorder{}
+		if _, _, err := r.Dispatch(test.path, nil); err != nil {
+			t.Errorf("Dispatch(%q): unexpected error: %v", test.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(rec, test.want) {
+			t.Errorf("Dispatch(%q): expected %q, got %q", test.path, test.want, rec)
+		}
+	}
+}
@@ -6,7 +6,6 @@ package mux
 
 import (
 	"fmt"
-	"net/url"
 	"path"
 	"regexp"
 
@@ -70,61 +69,103 @@ type Router struct {
 func (r *Router) Match(req *Request, match *RouteMatch) bool {
 	for _, route := range r.routes {
 		if route.Match(req, match) {
+			// Wrap the handler in the middlewares, the first one used
+			// outermost. A subrouter has wrapped it in its own already.
+			if match.MatchErr == nil {
+				for i := len(r.middlewares) - 1; i >= 0; i-- {
+					match.Handler = r.middlewares[i].Middleware(match.Handler)
+				}
+			}
 			return true
 		}
 	}
 
 	// Closest match for a router (includes sub-routers)
+	// A rejected route variable is a clearer error than not found.
+	if _, ok := match.MatchErr.(*VarError); !ok {
+		match.MatchErr = ErrNotFound
+	}
 	if r.NotFoundHandler != nil {
 		match.Handler = r.NotFoundHandler
-		match.MatchErr = ErrNotFound
 		return true
 	}
-
-	match.MatchErr = ErrNotFound
 	return false
 }
 
 // Dispatches the handler registered in the matched route.
 //
 // When there is a match, the route variables can be retrieved calling
-// mux.Vars(request).
+// mux.Vars(request). Redirects and aliases are followed first, see
+// Router.Redirect.
 func (r *Router) Dispatch(fullpath string, context map[string]interface{}) (tview.Primitive, *Request, error) {
-	u, err := url.Parse(fullpath)
+	req, match, err := r.Resolve(fullpath, context)
 	if err != nil {
-		return nil, nil, errors.Wrapf(err, "in Router.Dispatch(path): %q\n", fullpath)
+		return nil, req, err
 	}
+	return r.ServeMatch(req, match)
+}
+
+// Resolve is Dispatch without serving the match, so the request can be
+// checked before anything runs for it, see ServeMatch. The request has
+// the route variables and the current route set.
+func (r *Router) Resolve(fullpath string, context map[string]interface{}) (*Request, *RouteMatch, error) {
 	if context == nil {
 		context = make(map[string]interface{})
 	}
-	req := &Request{
-		Path:    u.Path,
-		Queries: u.Query(),
-		Context: context,
+	req, err := parseRequest(fullpath, context)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "in Router.Dispatch(path): %q\n", fullpath)
 	}
 
-	// Clean path to canonical form and redirect.
-	if p := cleanPath(req.Path); p != req.Path {
-		req.Path = p
+	req, err = r.follow(req)
+	if err != nil {
+		return req, nil, errors.Wrapf(err, "in Router.Dispatch(path): %q\n", fullpath)
 	}
 
-	return r.Serve(req)
+	req, match := r.matchRequest(req)
+	return req, match, nil
 }
 
 func (r *Router) Serve(req *Request) (tview.Primitive, *Request, error) {
-	var match RouteMatch
-	var handler Handler
-	if r.Match(req, &match) {
-		handler = match.Handler
+	return r.ServeMatch(r.matchRequest(req))
+}
+
+// matchRequest matches req, setting its vars and current route.
+func (r *Router) matchRequest(req *Request) (*Request, *RouteMatch) {
+	match := &RouteMatch{}
+	if r.Match(req, match) {
 		req = setVars(req, match.Vars)
+		req = setTypedVars(req, match.Typed)
 		req = setCurrentRoute(req, match.Route)
+	} else {
+		match.Handler = nil
 	}
-	// fmt.Printf("\n\n\n\nMatch !!!\n\n%+v\n\n%+v\n\n", req, match)
+	return req, match
+}
 
+// ServeMatch calls the handler of a match Resolve returned.
+func (r *Router) ServeMatch(req *Request, match *RouteMatch) (tview.Primitive, *Request, error) {
+	handler := match.Handler
 	if handler == nil {
 		handler = r.NotFoundHandler
 	}
 
+	// A converter rejected a route variable, the layout is never reached.
+	if verr, ok := match.MatchErr.(*VarError); ok {
+		if handler == nil {
+			return nil, req, verr
+		}
+		p, req, err := handler.Serve(req)
+		if err == nil {
+			err = verr
+		}
+		return p, req, err
+	}
+
+	if handler == nil {
+		return nil, req, errors.Wrapf(ErrNotFound, "%q", req.Path)
+	}
+
 	// finalize dispatch by calling the handler
 	return handler.Serve(req)
 }
@@ -275,6 +316,9 @@ type RouteMatch struct {
 	Handler Handler
 	Vars    map[string]string
 
+	// Typed has the variables converted by a Converter.
+	Typed map[string]interface{}
+
 	// MatchErr is set to appropriate matching error
 	// It is set to ErrMethodMismatch if there is a mismatch in
 	// the request method and route method
@@ -284,8 +328,9 @@ type RouteMatch struct {
 type contextKey int
 
 const (
-	varsKey  = "vars"
-	routeKey = "route"
+	varsKey      = "vars"
+	typedVarsKey = "typed-vars"
+	routeKey     = "route"
 )
 
 // Vars returns the route variables for the current request, if any.
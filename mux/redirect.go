@@ -0,0 +1,119 @@
+package mux
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/verdverm/tview"
+)
+
+// MaxRedirects is how many redirects a single Dispatch follows,
+// and how many times guards may redirect a navigation in a router.
+const MaxRedirects = 10
+
+// ErrRedirectLoop is the cause of the Dispatch error when redirects
+// and aliases lead back to a path already visited.
+var ErrRedirectLoop = errors.New("mux: redirect loop")
+
+const redirectedFromKey = "redirected-from"
+
+// redirect is where a redirect route sends Dispatch,
+// a path template or the name of a route.
+type redirect struct {
+	to    *routeRegexp
+	alias string
+}
+
+// Redirect registers a route which sends Dispatch on to another path.
+// The target is a path template filled in with the variables of from,
+// "/u/{id}" to "/users/{id}", the query string is kept.
+func (r *Router) Redirect(from, to string) *Route {
+	route := r.NewRoute().Path(from)
+	tpl, err := newRouteRegexp(to, regexpTypePath, routeRegexpOptions{})
+	if err != nil && route.err == nil {
+		route.err = errors.Wrapf(err, "mux: redirect target %q", to)
+	}
+	route.redirect = &redirect{to: tpl}
+	return route.Handler(redirectHandler)
+}
+
+// Alias registers another path for the route with the given name. It
+// redirects to wherever the route is when dispatched, so the route can
+// move, the variables of the route are filled in from those of from.
+func (r *Router) Alias(from, name string) *Route {
+	route := r.NewRoute().Path(from)
+	route.redirect = &redirect{alias: name}
+	return route.Handler(redirectHandler)
+}
+
+// RedirectedFrom returns the paths Dispatch was redirected
+// from to reach the current request, oldest first.
+func RedirectedFrom(r *Request) []string {
+	if rv := r.Context[redirectedFromKey]; rv != nil {
+		return rv.([]string)
+	}
+	return nil
+}
+
+// redirectHandler serves redirect routes, which Dispatch follows instead.
+var redirectHandler = NewDefaultHandler(func(req *Request) (tview.Primitive, *Request, error) {
+	return nil, req, errors.Errorf("mux: %q is a redirect, use Dispatch to follow it", req.Path)
+})
+
+// target is the path the redirect sends to for the matched vars.
+func (d *redirect) target(r *Router, vars map[string]string) (string, error) {
+	if d.alias == "" {
+		return d.to.url(vars)
+	}
+	route := r.Get(d.alias)
+	if route == nil {
+		return "", errors.Errorf("mux: alias of unknown route %q", d.alias)
+	}
+	pairs := make([]string, 0, 2*len(vars))
+	for k, v := range vars {
+		pairs = append(pairs, k, v)
+	}
+	path, err := route.Substitute(pairs...)
+	return strings.TrimSuffix(path, "?"), err
+}
+
+// follow resolves redirect routes until req matches one which is not,
+// keeping the query values the targets do not set.
+func (r *Router) follow(req *Request) (*Request, error) {
+	visited := []string{}
+	for {
+		var match RouteMatch
+		if !r.Match(req, &match) || match.Route == nil || match.Route.redirect == nil {
+			break
+		}
+		visited = append(visited, req.Path)
+
+		to, err := match.Route.redirect.target(r, match.Vars)
+		if err != nil {
+			return req, errors.Wrapf(err, "redirecting from %q", req.Path)
+		}
+		next, err := parseRequest(to, req.Context)
+		if err != nil {
+			return req, errors.Wrapf(err, "redirecting from %q", req.Path)
+		}
+		for k, v := range req.Queries {
+			if _, ok := next.Queries[k]; !ok {
+				next.Queries[k] = v
+			}
+		}
+
+		loop := len(visited) >= MaxRedirects
+		for _, p := range visited {
+			loop = loop || p == next.Path
+		}
+		if loop {
+			return req, errors.Wrapf(ErrRedirectLoop, "%s -> %s", strings.Join(visited, " -> "), next.Path)
+		}
+		req = next
+	}
+
+	if len(visited) > 0 {
+		req.Context[redirectedFromKey] = visited
+	}
+	return req, nil
+}
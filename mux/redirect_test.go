@@ -0,0 +1,83 @@
+package mux
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/verdverm/tview"
+)
+
+func TestRedirect(t *testing.T) {
+	var served *Request
+	handler := NewDefaultHandler(func(req *Request) (tview.Primitive, *Request, error) {
+		served = req
+		return nil, req, nil
+	})
+	r := NewRouter()
+	r.Handle("/users/{id}", handler).Name("user")
+	r.Handle("/search", handler)
+	r.Redirect("/u/{id}", "/users/{id}")
+	r.Redirect("/find", "/search?sort=name")
+	r.Alias("/people/{id}", "user")
+	old := r.PathPrefix("/old").Subrouter()
+	old.Redirect("/u/{id}", "/u/{id}")
+
+	tests := []struct {
+		path    string
+		want    string
+		queries map[string][]string
+		from    []string
+	}{
+		{"/users/7", "/users/7", map[string][]string{}, nil},
+		{"/u/7", "/users/7", map[string][]string{}, []string{"/u/7"}},
+		{"/u/7?tab=logs", "/users/7", map[string][]string{"tab": {"logs"}}, []string{"/u/7"}},
+		{"/people/9", "/users/9", map[string][]string{}, []string{"/people/9"}},
+		{"/old/u/3", "/users/3", map[string][]string{}, []string{"/old/u/3", "/u/3"}},
+		// the target's query values win
+		{"/find?sort=date&q=x", "/search", map[string][]string{"sort": {"name"}, "q": {"x"}}, []string{"/find"}},
+	}
+
+	for _, test := range tests {
+		served = nil
+		_, _, err := r.Dispatch(test.path, nil)
+		if err != nil {
+			t.Errorf("Dispatch(%q): unexpected error: %v", test.path, err)
+			continue
+		}
+		if served == nil {
+			t.Errorf("Dispatch(%q): nothing served", test.path)
+			continue
+		}
+		if served.Path != test.want || !reflect.DeepEqual(served.Queries, test.queries) {
+			t.Errorf("Dispatch(%q): expected %s%v, got %s%v", test.path, test.want, test.queries, served.Path, served.Queries)
+		}
+		if from := RedirectedFrom(served); !reflect.DeepEqual(from, test.from) {
+			t.Errorf("Dispatch(%q): expected redirects from %q, got %q", test.path, test.from, from)
+		}
+	}
+}
+
+func TestRedirectLoop(t *testing.T) {
+	r := NewRouter()
+	r.Redirect("/a", "/b")
+	r.Redirect("/b", "/a")
+	r.Redirect("/self", "/self")
+	for i := 0; i < MaxRedirects+1; i++ {
+		r.Redirect(fmt.Sprintf("/chain/%d", i), fmt.Sprintf("/chain/%d", i+1))
+	}
+	r.Alias("/nowhere", "missing")
+
+	for _, path := range []string{"/a", "/self", "/chain/0"} {
+		if _, _, err := r.Dispatch(path, nil); errors.Cause(err) != ErrRedirectLoop {
+			t.Errorf("Dispatch(%q): expected a redirect loop, got %v", path, err)
+		}
+	}
+	if _, _, err := r.Dispatch("/nowhere", nil); err == nil || errors.Cause(err) == ErrRedirectLoop {
+		t.Errorf("Dispatch(%q): expected an unknown route error, got %v", "/nowhere", err)
+	}
+	if err := NewRouter().Redirect("/z", "/{bad").GetError(); err == nil {
+		t.Error("expected an error for a bad redirect target")
+	}
+}
@@ -61,6 +61,7 @@ func newRouteRegexp(tpl string, typ regexpType, options routeRegexpOptions) (*ro
 	}
 	varsN := make([]string, len(idxs)/2)
 	varsR := make([]*regexp.Regexp, len(idxs)/2)
+	varsC := make([]*varConverter, len(idxs)/2)
 	pattern := bytes.NewBufferString("")
 	pattern.WriteByte('^')
 	reverse := bytes.NewBufferString("")
@@ -81,6 +82,17 @@ func newRouteRegexp(tpl string, typ regexpType, options routeRegexpOptions) (*ro
 			return nil, fmt.Errorf("mux: missing name or pattern in %q",
 				tpl[idxs[i]:end])
 		}
+		// A converter name as the pattern, {uuid} is short for {uuid:uuid}.
+		var conv string
+		if len(parts) == 2 {
+			conv = patt
+		} else if name == "uuid" {
+			conv = name
+		}
+		if c, ok := lookupConverter(conv); ok && conv != "" {
+			patt = c.Pattern
+			varsC[i/2] = &varConverter{typ: conv, convert: c.Convert}
+		}
 		// Build the regexp pattern.
 		fmt.Fprintf(pattern, "%s(?P<%s>%s)", regexp.QuoteMeta(raw), varGroupName(i/2), patt)
 
@@ -134,6 +146,7 @@ func newRouteRegexp(tpl string, typ regexpType, options routeRegexpOptions) (*ro
 		reverse:    reverse.String(),
 		varsN:      varsN,
 		varsR:      varsR,
+		varsC:      varsC,
 	}, nil
 }
 
@@ -154,6 +167,8 @@ type routeRegexp struct {
 	varsN []string
 	// Variable regexps (validators).
 	varsR []*regexp.Regexp
+	// Variable converters, nil for plain string variables.
+	varsC []*varConverter
 }
 
 // Match matches the regexp against the URL host or path.
@@ -1,8 +1,31 @@
 package mux
 
+import (
+	"net/url"
+)
+
 type Request struct {
 	Path    string
 	Queries map[string][]string
 
 	Context map[string]interface{}
 }
+
+// parseRequest makes the request for a path with a query string.
+func parseRequest(fullpath string, context map[string]interface{}) (*Request, error) {
+	u, err := url.Parse(fullpath)
+	if err != nil {
+		return nil, err
+	}
+	req := &Request{
+		Path:    u.Path,
+		Queries: u.Query(),
+		Context: context,
+	}
+
+	// Clean path to canonical form and redirect.
+	if p := cleanPath(req.Path); p != req.Path {
+		req.Path = p
+	}
+	return req, nil
+}
@@ -29,6 +29,9 @@ type Route struct {
 	err error
 
 	buildVarsFunc BuildVarsFunc
+
+	// Where Dispatch goes instead, for redirects and aliases.
+	redirect *redirect
 }
 
 // Match matches the route against the request.
@@ -42,6 +45,11 @@ func (r *Route) Match(req *Request, match *RouteMatch) bool {
 	// Match everything.
 	for _, m := range r.matchers {
 		if matched := m.Match(req, match); !matched {
+			// A subrouter which matched nothing set ErrNotFound, clear
+			// it so a later route which matches runs its middlewares.
+			if match.MatchErr == ErrNotFound {
+				match.MatchErr = nil
+			}
 			matchErr = nil
 			return false
 		}
@@ -73,6 +81,16 @@ func (r *Route) Match(req *Request, match *RouteMatch) bool {
 	// Set variables.
 	if r.regexp != nil {
 		r.regexp.setMatch(req, match, r)
+		// A variable its converter rejects fails the match,
+		// the error is kept unless a later route matches.
+		if err := r.regexp.convert(match); err != nil {
+			match.Route, match.Handler, match.Vars, match.Typed = nil, nil, nil, nil
+			match.MatchErr = err
+			return false
+		}
+	}
+	if _, ok := match.MatchErr.(*VarError); ok {
+		match.MatchErr = nil
 	}
 	return true
 }
@@ -208,6 +226,10 @@ func (r *Route) MatcherFunc(f MatcherFunc) *Route {
 //
 // - {name:pattern} matches the given regexp pattern.
 //
+// - {name:type} matches and converts with the Converter registered as
+// type, the built-in ones are "int", "time" and "uuid". {uuid} is short
+// for {uuid:uuid}, other names are not looked up as converters.
+//
 // For example:
 //
 //     r := mux.NewRouter()
@@ -215,9 +237,10 @@ func (r *Route) MatcherFunc(f MatcherFunc) *Route {
 //     r.Path("/products/{key}").Handler(ProductsHandler)
 //     r.Path("/articles/{category}/{id:[0-9]+}").
 //       Handler(ArticleHandler)
+//     r.Path("/jobs/{id:int}").Handler(JobHandler)
 //
 // Variable names must be unique in a given route. They can be retrieved
-// calling mux.Vars(request).
+// calling mux.Vars(request), and converted ones mux.TypedVars(request).
 func (r *Route) Path(tpl string) *Route {
 	r.err = r.addRegexpMatcher(tpl, regexpTypePath)
 	return r
@@ -0,0 +1,157 @@
+package vermui
+
+import (
+	"sync"
+
+	"github.com/gdamore/tcell"
+	"github.com/verdverm/tview"
+
+	"github.com/verdverm/vermui/events"
+)
+
+// PlaceFunc returns where an overlay goes on a screen of the given size.
+// The root is always full screen, so the rect is in screen coordinates.
+type PlaceFunc func(width, height int) (x, y, w, h int)
+
+type overlay struct {
+	name  string
+	item  tview.Primitive
+	place PlaceFunc
+}
+
+// layers is the application root, it draws the
+// root view and then any overlays on top of it.
+type layers struct {
+	*tview.Box
+
+	mu       sync.Mutex
+	overlays []*overlay
+}
+
+var rootLayers = &layers{
+	Box: tview.NewBox(),
+}
+
+func (L *layers) Draw(screen tcell.Screen) {
+	// the frame is shown here, not after, so writeTerminal can't
+	// put its sequences in the middle of the screen output
+	termMu.Lock()
+	defer termMu.Unlock()
+	defer screen.Show()
+
+	// mouse events only go to the widgets drawn in this frame
+	events.BeginFrame()
+	defer events.EndFrame()
+
+	x, y, w, h := L.GetRect()
+	if rootView != nil {
+		rootView.SetRect(x, y, w, h)
+		rootView.Draw(screen)
+	}
+
+	L.mu.Lock()
+	ovs := append([]*overlay{}, L.overlays...)
+	L.mu.Unlock()
+
+	for _, o := range ovs {
+		o.item.SetRect(o.place(w, h))
+		o.item.Draw(screen)
+	}
+}
+
+func (L *layers) Focus(delegate func(p tview.Primitive)) {
+	if rootView != nil {
+		delegate(rootView)
+	}
+}
+
+// ShowOverlay draws item on top of the root view where place says,
+// replacing any overlay with the same name. It does not take the focus.
+func ShowOverlay(name string, item tview.Primitive, place PlaceFunc) {
+	rootLayers.mu.Lock()
+	o := &overlay{name: name, item: item, place: place}
+	found := false
+	for i, old := range rootLayers.overlays {
+		if old.name == name {
+			rootLayers.overlays[i] = o
+			found = true
+		}
+	}
+	if !found {
+		rootLayers.overlays = append(rootLayers.overlays, o)
+	}
+	rootLayers.mu.Unlock()
+
+	Draw()
+}
+
+// HideOverlay removes the named overlay.
+func HideOverlay(name string) {
+	rootLayers.mu.Lock()
+	ovs := rootLayers.overlays[:0]
+	for _, o := range rootLayers.overlays {
+		if o.name != name {
+			ovs = append(ovs, o)
+		}
+	}
+	rootLayers.overlays = ovs
+	rootLayers.mu.Unlock()
+
+	Draw()
+}
+
+// HasOverlay reports whether the named overlay is shown.
+func HasOverlay(name string) bool {
+	rootLayers.mu.Lock()
+	defer rootLayers.mu.Unlock()
+
+	for _, o := range rootLayers.overlays {
+		if o.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Centered places an overlay in the middle of the screen,
+// shrinking it if the screen is too small.
+func Centered(width, height int) PlaceFunc {
+	return func(sw, sh int) (int, int, int, int) {
+		w, h := width, height
+		if w > sw {
+			w = sw
+		}
+		if h > sh {
+			h = sh
+		}
+		return (sw - w) / 2, (sh - h) / 2, w, h
+	}
+}
+
+// Below places an overlay under (or above, if there is no room)
+// the anchor primitive, aligned with its left edge.
+func Below(anchor tview.Primitive, width, height int) PlaceFunc {
+	return func(sw, sh int) (int, int, int, int) {
+		ax, ay, _, ah := anchor.GetRect()
+		w, h := width, height
+		if w > sw {
+			w = sw
+		}
+		x := ax
+		if x+w > sw {
+			x = sw - w
+		}
+		y := ay + ah
+		if y+h > sh {
+			// not enough room below, go above
+			y = ay - h
+			if y < 0 {
+				y = 0
+				if h > ay {
+					h = ay
+				}
+			}
+		}
+		return x, y, w, h
+	}
+}
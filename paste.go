@@ -0,0 +1,64 @@
+package vermui
+
+import (
+	"strings"
+
+	"github.com/verdverm/tview"
+
+	"github.com/verdverm/vermui/events"
+)
+
+// BracketedPaste asks the terminal to mark pastes, so they arrive
+// as one "/sys/paste" event rather than keys. Set it before Start.
+var BracketedPaste = true
+
+// setBracketedPaste turns the terminal's bracketed paste mode on or off.
+func setBracketedPaste(on bool) {
+	if !BracketedPaste {
+		return
+	}
+	seq := "\x1b[?2004l"
+	if on {
+		seq = "\x1b[?2004h"
+	}
+	writeTerminal(seq)
+}
+
+// Paster is implemented by widgets which accept pasted text.
+// The whole paste is delivered at once, rather than as keys.
+type Paster interface {
+	Paste(text string)
+}
+
+// Paste delivers text to the focused widget, if it is a Paster
+// or an InputField. This is what "/sys/paste" events do.
+func Paste(text string) {
+	if app == nil || text == "" {
+		return
+	}
+
+	switch w := GetFocus().(type) {
+	case Paster:
+		w.Paste(text)
+	case *tview.InputField:
+		PasteInput(w, text)
+	default:
+		return
+	}
+	Draw()
+}
+
+// PasteInput inserts text into a single line InputField.
+// Newlines are turned into spaces so nothing is submitted.
+func PasteInput(field *tview.InputField, text string) {
+	text = strings.TrimRight(text, "\r\n")
+	text = strings.Replace(text, "\r\n", " ", -1)
+	text = strings.Replace(text, "\n", " ", -1)
+	field.SetText(field.GetText() + text)
+}
+
+func pasteHandler(e events.Event) {
+	if p, ok := e.Data.(events.EventPaste); ok {
+		Paste(p.Text)
+	}
+}
@@ -0,0 +1,113 @@
+package vermui
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/verdverm/vermui/events"
+)
+
+// SuspendKey drops to a shell, set it before Init.
+// An empty string disables the binding.
+var SuspendKey = "C-z"
+
+// Suspend releases the terminal, runs f and then restores the screen,
+// all widgets keep their state. "/sys/suspend" is published before f
+// runs and "/sys/resume" after. It returns false if the application
+// is not running.
+func Suspend(f func()) bool {
+	if app == nil {
+		return false
+	}
+
+	// not in goroutines, so suspend is sent first and before f runs
+	events.SendCustomEvent("/sys/suspend", nil)
+
+	ok := app.Suspend(func() {
+		// programs run meanwhile may not know the paste markers
+		setBracketedPaste(false)
+		defer setBracketedPaste(true)
+		f()
+	})
+
+	events.SendCustomEvent("/sys/resume", nil)
+	Draw()
+
+	return ok
+}
+
+// Shell suspends the application and runs an interactive $SHELL.
+func Shell() error {
+	sh := os.Getenv("SHELL")
+	if sh == "" {
+		sh = "/bin/sh"
+	}
+	return Exec(sh)
+}
+
+// Exec suspends the application and runs a command
+// attached to the terminal until it exits.
+func Exec(name string, args ...string) error {
+	var err error
+	ok := Suspend(func() {
+		cmd := exec.Command(name, args...)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		err = cmd.Run()
+	})
+	if !ok {
+		return errors.New("vermui: unable to suspend, not running")
+	}
+	return err
+}
+
+// Edit opens text in $VISUAL or $EDITOR, which may have arguments
+// ("code -w"), and returns the edited result.
+func Edit(text string) (string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if strings.TrimSpace(editor) == "" {
+		editor = "vi"
+	}
+
+	f, err := ioutil.TempFile("", "vermui-edit-")
+	if err != nil {
+		return text, errors.Wrap(err, "in vermui.Edit")
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(text)
+	f.Close()
+	if err != nil {
+		return text, errors.Wrap(err, "in vermui.Edit")
+	}
+
+	args := strings.Fields(editor)
+	err = Exec(args[0], append(args[1:], f.Name())...)
+	if err != nil {
+		return text, errors.Wrapf(err, "in vermui.Edit running %q", editor)
+	}
+
+	data, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		return text, errors.Wrap(err, "in vermui.Edit")
+	}
+	return string(data), nil
+}
+
+func suspendHandler(e events.Event) {
+	// keep the event loop running while the shell is up
+	go func() {
+		err := Shell()
+		if err != nil {
+			events.SendCustomEvent("/console/error", errors.Wrap(err, "in suspend to shell"))
+		}
+	}()
+}
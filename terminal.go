@@ -0,0 +1,26 @@
+package vermui
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// termMu serializes the escape sequences vermui writes around tcell,
+// which has no API for them, with the drawing of the screen.
+var termMu sync.Mutex
+
+// writeTerminal writes seq to the terminal between screen updates.
+func writeTerminal(seq string) error {
+	termMu.Lock()
+	defer termMu.Unlock()
+
+	var w io.Writer = os.Stdout
+	if tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0); err == nil {
+		defer tty.Close()
+		w = tty
+	}
+
+	_, err := io.WriteString(w, seq)
+	return err
+}
@@ -1,14 +1,8 @@
 package vermui
 
 import (
-	"bytes"
-	"fmt"
-	"io"
-	"os"
-	"runtime/debug"
 	"sync"
 
-	"github.com/maruel/panicparse/stack"
 	"github.com/verdverm/tview"
 
 	"github.com/verdverm/vermui/events"
@@ -18,6 +12,10 @@ var app *tview.Application
 var appLock sync.RWMutex
 var rootView tview.Primitive
 
+// ClickToFocus moves the focus to the widget under the pointer
+// whenever a mouse button is pressed.
+var ClickToFocus = true
+
 // Init initializes vermui library. This function should be called before any others.
 // After initialization, the library must be finalized by 'Close' function.
 func Init() error {
@@ -34,46 +32,48 @@ func Init() error {
 		Draw()
 	})
 
+	events.AddGlobalHandler("/sys/paste", pasteHandler)
+
+	addClipboardHandlers()
+
+	if SuspendKey != "" {
+		events.AddGlobalHandler("/sys/key/"+SuspendKey, suspendHandler)
+	}
+
+	events.AddGlobalHandler("/sys/mouse/down", func(e events.Event) {
+		if !ClickToFocus || e.To == "" {
+			return
+		}
+		if w := events.GetWidget(e.To); w != nil && w != GetFocus() {
+			SetFocus(w)
+		}
+	})
+
 	return nil
 }
 
 // blocking call
 func Start() error {
 
-	// catch panics, clean up, format error
-	defer func() {
-		e := recover()
-		if e != nil {
-			Stop()
-			// Print a formatted panic output
-			fmt.Fprintf(os.Stderr, "Captured a panic(value=%v) lib.Start()... Exit vermui and clean terminal...\nPrint stack trace:\n\n", e)
-			//debug.PrintStack()
-			gs, err := stack.ParseDump(bytes.NewReader(debug.Stack()), os.Stderr)
-			if err != nil {
-				debug.PrintStack()
-				os.Exit(1)
-			}
-			p := &stack.Palette{}
-			buckets := stack.SortBuckets(stack.Bucketize(gs, stack.AnyValue))
-			srcLen, pkgLen := stack.CalcLengths(buckets, false)
-			for _, bucket := range buckets {
-				io.WriteString(os.Stdout, p.BucketHeader(&bucket, false, len(buckets) > 1))
-				io.WriteString(os.Stdout, p.StackLines(&bucket.Signature, srcLen, pkgLen, false))
-			}
-			panic(e)
-		}
-	}()
+	// catch panics, clean up, write a crash report
+	defer crash()
 
 	// start the event engine
-	go events.Start()
+	go func() {
+		defer crash()
+		events.Start()
+	}()
 
 	err := rootView.Mount(nil)
 	if err != nil {
 		panic(err)
 	}
 
+	setBracketedPaste(true)
+	defer setBracketedPaste(false)
+
 	// blocking
-	app.SetRoot(rootView, true)
+	app.SetRoot(rootLayers, true)
 	return app.Run()
 }
 
@@ -99,6 +99,10 @@ func Draw() {
 	appLock.RLock()
 	defer appLock.RUnlock()
 
+	if app == nil {
+		// not started, there is nothing to draw on
+		return
+	}
 	go app.Draw()
 }
 
@@ -171,6 +175,12 @@ func ClearGlobalHandlers() {
 	events.ClearGlobalHandlers()
 }
 
+// AddWidget makes a widget a target for mouse events
+// (and click-to-focus) without adding any handlers.
+func AddWidget(widget tview.Primitive) {
+	events.AddWidget(widget)
+}
+
 func AddWidgetHandler(widget tview.Primitive, path string, handler func(events.Event)) {
 	events.AddWidgetHandler(widget, path, handler)
 }